@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	mcp "github.com/metoro-io/mcp-golang"
+	"golang.org/x/net/publicsuffix"
+)
+
+// CAARecord is a single decoded CAA property, as found at some ancestor of
+// the domain being audited.
+type CAARecord struct {
+	Critical bool   `json:"critical"`
+	Tag      string `json:"tag"`
+	Value    string `json:"value"`
+}
+
+// CAAResult is the response shape for the lookup_caa tool: the records found
+// while walking the CAA tree, the domain they were found at, and, if an
+// issuer was supplied, whether that issuer is authorized to issue for it.
+type CAAResult struct {
+	Domain             string      `json:"domain"`
+	Records            []CAARecord `json:"records,omitempty"`
+	PermittedForIssuer *bool       `json:"permitted_for_issuer,omitempty"`
+}
+
+type CAALookup struct {
+	Domain string `json:"domain" jsonschema:"required,description=The domain name to audit CAA records for (e.g., example.com)"`
+	Issuer string `json:"issuer,omitempty" jsonschema:"description=CA issuer domain to check for issuance authorization (e.g., letsencrypt.org)"`
+}
+
+// walkCAATree queries CAA records per RFC 8659 section 5.3: starting at the
+// FQDN and climbing one label at a time, stopping at the first ancestor that
+// has any CAA records, or once only the public suffix (e.g. "com", "co.uk",
+// "github.io") is left.
+func walkCAATree(ctx context.Context, resolver DNSResolver, domain string) (string, []CAARecord) {
+	name := strings.TrimSuffix(domain, ".")
+	// Resolved once up front against the public suffix list, rather than a
+	// naive dot-count, so multi-label public suffixes (co.uk, com.au,
+	// github.io) stop the climb at the right label instead of one past it.
+	boundary, _ := publicsuffix.PublicSuffix(name)
+
+	for {
+		records, err := resolver.LookupCAA(ctx, name)
+		if err != nil {
+			log.Printf("CAA lookup error for %s: %v", name, err)
+		} else if len(records) > 0 {
+			return name, records
+		}
+
+		labels := strings.Split(name, ".")
+		if len(labels) <= 1 || name == boundary {
+			return name, nil
+		}
+
+		parent := strings.Join(labels[1:], ".")
+		if parent == boundary {
+			// Climbing further would query the bare public suffix itself
+			// (e.g. "com", "co.uk"), which RFC 8659 says to stop short of.
+			return name, nil
+		}
+		name = parent
+	}
+}
+
+// caaPermittedForIssuer applies RFC 8659 section 5.3's issuance check: the
+// issuer is authorized if no unknown critical tag is present and, when any
+// issue/issuewild tags exist, one of them names the issuer. An empty set of
+// issue/issuewild tags means the domain has not restricted issuance at all.
+func caaPermittedForIssuer(records []CAARecord, issuer string) bool {
+	issuer = strings.ToLower(strings.TrimSpace(issuer))
+
+	hasIssueTags := false
+	permitted := false
+	for _, rec := range records {
+		switch strings.ToLower(rec.Tag) {
+		case "issue", "issuewild":
+			hasIssueTags = true
+			if caaValueNamesIssuer(rec.Value, issuer) {
+				permitted = true
+			}
+		case "iodef":
+			// Informational only; doesn't bear on issuance authorization.
+		default:
+			if rec.Critical {
+				return false
+			}
+		}
+	}
+
+	if !hasIssueTags {
+		return true
+	}
+	return permitted
+}
+
+// caaValueNamesIssuer checks an issue/issuewild property value (the issuer
+// domain, optionally followed by ";"-separated parameters) against issuer.
+// A bare ";" value explicitly authorizes no issuer.
+func caaValueNamesIssuer(value, issuer string) bool {
+	name, _, _ := strings.Cut(value, ";")
+	return strings.ToLower(strings.TrimSpace(name)) == issuer
+}
+
+func lookupCAA(resolver DNSResolver, domain, issuer string) *CAAResult {
+	log.Printf("Walking CAA tree for: %s", domain)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	foundAt, records := walkCAATree(ctx, resolver, domain)
+	result := &CAAResult{Domain: foundAt, Records: records}
+
+	if issuer != "" {
+		permitted := caaPermittedForIssuer(records, issuer)
+		result.PermittedForIssuer = &permitted
+	}
+
+	log.Printf("CAA result for %s: found %d record(s) at %s", domain, len(records), foundAt)
+	return result
+}
+
+func lookupCAAMCP(resolver DNSResolver, args CAALookup) (*mcp.ToolResponse, error) {
+	log.Printf("Received CAA lookup request for %s (issuer=%q)", args.Domain, args.Issuer)
+
+	result := lookupCAA(resolver, args.Domain, args.Issuer)
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Error marshalling CAA result to JSON: %v", err)
+		errorMsg := fmt.Sprintf("Error formatting CAA result for %s", args.Domain)
+		return mcp.NewToolResponse(mcp.NewTextContent(errorMsg)), nil
+	}
+
+	jsonString := string(jsonBytes)
+	log.Printf("Responding with JSON string: %s", jsonString)
+
+	return mcp.NewToolResponse(mcp.NewTextContent(jsonString)), nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWalkCAATree(t *testing.T) {
+	resolver := NewMockDNSResolver()
+	resolver.SetCAA("www.example.com", nil, nil)
+	resolver.SetCAA("example.com", []CAARecord{{Tag: "issue", Value: "letsencrypt.org"}}, nil)
+	resolver.SetCAA("com", nil, errors.New("should not be queried"))
+
+	foundAt, records := walkCAATree(context.Background(), resolver, "www.example.com")
+	if foundAt != "example.com" {
+		t.Errorf("walkCAATree() foundAt = %q, want %q", foundAt, "example.com")
+	}
+	if len(records) != 1 || records[0].Value != "letsencrypt.org" {
+		t.Errorf("walkCAATree() records = %v, want one issue=letsencrypt.org record", records)
+	}
+}
+
+func TestWalkCAATreeNoRecords(t *testing.T) {
+	resolver := NewMockDNSResolver()
+	resolver.SetCAA("sub.example.net", nil, nil)
+	resolver.SetCAA("example.net", nil, nil)
+
+	foundAt, records := walkCAATree(context.Background(), resolver, "sub.example.net")
+	if foundAt != "example.net" {
+		t.Errorf("walkCAATree() foundAt = %q, want %q", foundAt, "example.net")
+	}
+	if len(records) != 0 {
+		t.Errorf("walkCAATree() records = %v, want none", records)
+	}
+}
+
+func TestWalkCAATreeStopsBeforeMultiLabelPublicSuffix(t *testing.T) {
+	resolver := NewMockDNSResolver()
+	resolver.SetCAA("www.example.co.uk", nil, nil)
+	resolver.SetCAA("example.co.uk", nil, nil)
+	resolver.SetCAA("co.uk", nil, errors.New("should not be queried: co.uk is the public suffix, not a registrable domain"))
+
+	foundAt, records := walkCAATree(context.Background(), resolver, "www.example.co.uk")
+	if foundAt != "example.co.uk" {
+		t.Errorf("walkCAATree() foundAt = %q, want %q", foundAt, "example.co.uk")
+	}
+	if len(records) != 0 {
+		t.Errorf("walkCAATree() records = %v, want none", records)
+	}
+}
+
+func TestCAAPermittedForIssuer(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   []CAARecord
+		issuer    string
+		permitted bool
+	}{
+		{
+			name:      "no records means unrestricted",
+			records:   nil,
+			issuer:    "letsencrypt.org",
+			permitted: true,
+		},
+		{
+			name: "matching issue tag",
+			records: []CAARecord{
+				{Tag: "issue", Value: "letsencrypt.org"},
+			},
+			issuer:    "letsencrypt.org",
+			permitted: true,
+		},
+		{
+			name: "non-matching issue tag",
+			records: []CAARecord{
+				{Tag: "issue", Value: "digicert.com"},
+			},
+			issuer:    "letsencrypt.org",
+			permitted: false,
+		},
+		{
+			name: "matching issuewild tag",
+			records: []CAARecord{
+				{Tag: "issue", Value: "digicert.com"},
+				{Tag: "issuewild", Value: "letsencrypt.org"},
+			},
+			issuer:    "letsencrypt.org",
+			permitted: true,
+		},
+		{
+			name: "value with parameters still matches on domain",
+			records: []CAARecord{
+				{Tag: "issue", Value: "letsencrypt.org; validationmethods=dns-01"},
+			},
+			issuer:    "letsencrypt.org",
+			permitted: true,
+		},
+		{
+			name: "unknown critical tag forces denial",
+			records: []CAARecord{
+				{Tag: "issue", Value: "letsencrypt.org"},
+				{Critical: true, Tag: "futuretag", Value: "whatever"},
+			},
+			issuer:    "letsencrypt.org",
+			permitted: false,
+		},
+		{
+			name: "unknown non-critical tag is ignored",
+			records: []CAARecord{
+				{Tag: "issue", Value: "letsencrypt.org"},
+				{Tag: "futuretag", Value: "whatever"},
+			},
+			issuer:    "letsencrypt.org",
+			permitted: true,
+		},
+		{
+			name: "iodef tag is informational only",
+			records: []CAARecord{
+				{Tag: "issue", Value: "letsencrypt.org"},
+				{Tag: "iodef", Value: "mailto:security@example.com"},
+			},
+			issuer:    "letsencrypt.org",
+			permitted: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := caaPermittedForIssuer(tc.records, tc.issuer); got != tc.permitted {
+				t.Errorf("caaPermittedForIssuer(%v, %q) = %v, want %v", tc.records, tc.issuer, got, tc.permitted)
+			}
+		})
+	}
+}
+
+func TestLookupCAA(t *testing.T) {
+	resolver := NewMockDNSResolver()
+	resolver.SetCAA("example.com", []CAARecord{{Tag: "issue", Value: "letsencrypt.org"}}, nil)
+
+	result := lookupCAA(resolver, "example.com", "letsencrypt.org")
+	if result.Domain != "example.com" {
+		t.Errorf("lookupCAA().Domain = %q, want %q", result.Domain, "example.com")
+	}
+	if len(result.Records) != 1 {
+		t.Fatalf("lookupCAA().Records = %v, want one record", result.Records)
+	}
+	if result.PermittedForIssuer == nil || !*result.PermittedForIssuer {
+		t.Errorf("lookupCAA().PermittedForIssuer = %v, want true", result.PermittedForIssuer)
+	}
+
+	resultNoIssuer := lookupCAA(resolver, "example.com", "")
+	if resultNoIssuer.PermittedForIssuer != nil {
+		t.Errorf("lookupCAA() with no issuer PermittedForIssuer = %v, want nil", resultNoIssuer.PermittedForIssuer)
+	}
+}
+
+func TestLookupCAAMCP(t *testing.T) {
+	resolver := NewMockDNSResolver()
+	resolver.SetCAA("example.com", []CAARecord{{Tag: "issue", Value: "letsencrypt.org"}}, nil)
+
+	resp, err := lookupCAAMCP(resolver, CAALookup{Domain: "example.com", Issuer: "digicert.com"})
+	if err != nil {
+		t.Errorf("lookupCAAMCP() error = %v, want nil", err)
+	}
+	if resp == nil || len(resp.Content) == 0 {
+		t.Fatalf("lookupCAAMCP() response missing content")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPositiveCacheTTL bounds how long a resolved lookup is cached,
+	// unless the domain's own expiration date (when RDAP reports one) is
+	// sooner.
+	defaultPositiveCacheTTL = 1 * time.Hour
+	// defaultNegativeCacheTTL is used for unresolved/unknown results, so a
+	// transient upstream failure doesn't stick around for as long as a real
+	// answer would.
+	defaultNegativeCacheTTL = 60 * time.Second
+)
+
+// Cache stores domain lookup results keyed by an opaque string (lookupDomain
+// uses "<tier>:<domain>", so each tier's answer is cached independently).
+type Cache interface {
+	Get(key string) (info *DomainInfo, ok bool)
+	Set(key string, info *DomainInfo, ttl time.Duration)
+}
+
+// cacheTTL picks how long to cache a tier's result for a domain lookup.
+func cacheTTL(info *DomainInfo) time.Duration {
+	if info == nil || info.Status == StatusUnknown {
+		return defaultNegativeCacheTTL
+	}
+	if info.Status == StatusRateLimited {
+		// A rate-limited result reflects our own throttling (or a 429),
+		// not the domain's real state, so it must expire quickly -
+		// otherwise it would keep being served as the answer long after
+		// the token bucket refilled and the upstream would answer again.
+		if info.RetryAfter != "" {
+			if secs, err := strconv.Atoi(info.RetryAfter); err == nil && secs > 0 {
+				return time.Duration(secs) * time.Second
+			}
+		}
+		return defaultNegativeCacheTTL
+	}
+	ttl := defaultPositiveCacheTTL
+	// CacheMaxAge carries the RDAP response's own Cache-Control hint, when
+	// the server sent one; honor it over our flat default.
+	if info.CacheMaxAge > 0 && info.CacheMaxAge < ttl {
+		ttl = info.CacheMaxAge
+	}
+	if info.ExpirationDate != "" {
+		if expires, err := time.Parse(time.RFC3339, info.ExpirationDate); err == nil {
+			if remaining := time.Until(expires); remaining > 0 && remaining < ttl {
+				ttl = remaining
+			}
+		}
+	}
+	return ttl
+}
+
+type cacheEntry struct {
+	info      *DomainInfo
+	expiresAt time.Time
+}
+
+type lruItem struct {
+	key   string
+	entry cacheEntry
+}
+
+// MemoryCache is an in-memory, size-bounded LRU cache with per-entry TTLs.
+// It's the default Cache implementation; FileCache wraps one to add
+// persistence.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*DomainInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	item := el.Value.(*lruItem)
+	if time.Now().After(item.entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return item.entry.info, true
+}
+
+func (c *MemoryCache) Set(key string, info *DomainInfo, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := cacheEntry{info: info, expiresAt: time.Now().Add(ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+
+	el := c.order.PushFront(&lruItem{key: key, entry: entry})
+	c.entries[key] = el
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*lruItem).key)
+		}
+	}
+}
+
+// snapshot returns the still-live entries, for FileCache to persist.
+func (c *MemoryCache) snapshot() []fileCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]fileCacheEntry, 0, len(c.entries))
+	for key, el := range c.entries {
+		item := el.Value.(*lruItem)
+		if item.entry.expiresAt.After(now) {
+			entries = append(entries, fileCacheEntry{Key: key, Info: item.entry.info, ExpiresAt: item.entry.expiresAt})
+		}
+	}
+	return entries
+}
+
+type fileCacheEntry struct {
+	Key       string      `json:"key"`
+	Info      *DomainInfo `json:"info"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// FileCache is a MemoryCache that persists its contents to a JSON file on
+// every write, so a long-running MCP session's cache survives a restart.
+type FileCache struct {
+	mem  *MemoryCache
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileCache(path string, capacity int) (*FileCache, error) {
+	fc := &FileCache{mem: NewMemoryCache(capacity), path: path}
+	if err := fc.load(); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+func (fc *FileCache) load() error {
+	data, err := os.ReadFile(fc.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache file %s: %w", fc.path, err)
+	}
+
+	var entries []fileCacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse cache file %s: %w", fc.path, err)
+	}
+
+	now := time.Now()
+	for _, e := range entries {
+		if e.ExpiresAt.After(now) {
+			fc.mem.Set(e.Key, e.Info, e.ExpiresAt.Sub(now))
+		}
+	}
+	return nil
+}
+
+func (fc *FileCache) Get(key string) (*DomainInfo, bool) {
+	return fc.mem.Get(key)
+}
+
+func (fc *FileCache) Set(key string, info *DomainInfo, ttl time.Duration) {
+	fc.mem.Set(key, info, ttl)
+	fc.persist()
+}
+
+func (fc *FileCache) persist() {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	data, err := json.Marshal(fc.mem.snapshot())
+	if err != nil {
+		log.Printf("Error marshalling cache for persistence: %v", err)
+		return
+	}
+	if err := os.WriteFile(fc.path, data, 0644); err != nil {
+		log.Printf("Error writing cache file %s: %v", fc.path, err)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openrdap/rdap"
+)
+
+var _ Cache = (*MemoryCache)(nil)
+var _ Cache = (*FileCache)(nil)
+
+func TestMemoryCacheGetSet(t *testing.T) {
+	cache := NewMemoryCache(10)
+
+	if _, ok := cache.Get("rdap:example.com"); ok {
+		t.Fatalf("Get() on empty cache returned ok = true")
+	}
+
+	info := &DomainInfo{Status: StatusRegistered, Source: "rdap"}
+	cache.Set("rdap:example.com", info, time.Minute)
+
+	got, ok := cache.Get("rdap:example.com")
+	if !ok {
+		t.Fatalf("Get() after Set() = ok false, want true")
+	}
+	if got.Status != StatusRegistered {
+		t.Errorf("Get().Status = %q, want %q", got.Status, StatusRegistered)
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	cache := NewMemoryCache(10)
+	cache.Set("rdap:example.com", &DomainInfo{Status: StatusRegistered}, -time.Second)
+
+	if _, ok := cache.Get("rdap:example.com"); ok {
+		t.Errorf("Get() returned an already-expired entry")
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", &DomainInfo{Status: StatusRegistered}, time.Minute)
+	cache.Set("b", &DomainInfo{Status: StatusRegistered}, time.Minute)
+
+	// Touch "a" so "b" becomes the least recently used entry.
+	cache.Get("a")
+	cache.Set("c", &DomainInfo{Status: StatusRegistered}, time.Minute)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("Get(%q) = ok true, want evicted", "b")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Errorf("Get(%q) = ok false, want still present", "a")
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Errorf("Get(%q) = ok false, want present", "c")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     *DomainInfo
+		wantLong bool
+	}{
+		{"nil info is negative", nil, false},
+		{"unknown status is negative", &DomainInfo{Status: StatusUnknown}, false},
+		{"registered with no expiration uses default", &DomainInfo{Status: StatusRegistered}, true},
+		{
+			name:     "registered with far-future expiration uses default",
+			info:     &DomainInfo{Status: StatusRegistered, ExpirationDate: time.Now().Add(24 * time.Hour).Format(time.RFC3339)},
+			wantLong: true,
+		},
+		{
+			name:     "registered expiring soon is capped",
+			info:     &DomainInfo{Status: StatusRegistered, ExpirationDate: time.Now().Add(10 * time.Second).Format(time.RFC3339)},
+			wantLong: false,
+		},
+		{"rate limited with no retry_after is negative", &DomainInfo{Status: StatusRateLimited}, false},
+		{"rate limited with retry_after is negative", &DomainInfo{Status: StatusRateLimited, RetryAfter: "30"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ttl := cacheTTL(tc.info)
+			if tc.wantLong && ttl != defaultPositiveCacheTTL {
+				t.Errorf("cacheTTL() = %v, want %v", ttl, defaultPositiveCacheTTL)
+			}
+			if !tc.wantLong && ttl >= defaultPositiveCacheTTL {
+				t.Errorf("cacheTTL() = %v, want less than %v", ttl, defaultPositiveCacheTTL)
+			}
+		})
+	}
+}
+
+func TestCacheTTLHonorsRetryAfter(t *testing.T) {
+	ttl := cacheTTL(&DomainInfo{Status: StatusRateLimited, RetryAfter: "30"})
+	if want := 30 * time.Second; ttl != want {
+		t.Errorf("cacheTTL() = %v, want %v (the parsed retry_after)", ttl, want)
+	}
+}
+
+func TestCacheTTLRateLimitedWithoutRetryAfterUsesNegativeTTL(t *testing.T) {
+	ttl := cacheTTL(&DomainInfo{Status: StatusRateLimited})
+	if ttl != defaultNegativeCacheTTL {
+		t.Errorf("cacheTTL() = %v, want %v (defaultNegativeCacheTTL)", ttl, defaultNegativeCacheTTL)
+	}
+}
+
+func TestCacheTTLHonorsCacheControlMaxAge(t *testing.T) {
+	ttl := cacheTTL(&DomainInfo{Status: StatusRegistered, CacheMaxAge: 5 * time.Minute})
+	if want := 5 * time.Minute; ttl != want {
+		t.Errorf("cacheTTL() = %v, want %v (the Cache-Control max-age)", ttl, want)
+	}
+}
+
+func TestCacheTTLCacheControlMaxAgeDoesNotExtendPastExpiration(t *testing.T) {
+	info := &DomainInfo{
+		Status:         StatusRegistered,
+		CacheMaxAge:    time.Hour,
+		ExpirationDate: time.Now().Add(10 * time.Second).Format(time.RFC3339),
+	}
+	if ttl := cacheTTL(info); ttl >= time.Hour {
+		t.Errorf("cacheTTL() = %v, want less than %v (expiration is sooner than max-age)", ttl, time.Hour)
+	}
+}
+
+func TestFileCachePersistsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	cache, err := NewFileCache(path, 10)
+	if err != nil {
+		t.Fatalf("NewFileCache() error = %v", err)
+	}
+	cache.Set("rdap:example.com", &DomainInfo{Status: StatusRegistered, Source: "rdap"}, time.Minute)
+
+	reopened, err := NewFileCache(path, 10)
+	if err != nil {
+		t.Fatalf("NewFileCache() reopen error = %v", err)
+	}
+	got, ok := reopened.Get("rdap:example.com")
+	if !ok {
+		t.Fatalf("Get() after reopening file cache = ok false, want true")
+	}
+	if got.Status != StatusRegistered {
+		t.Errorf("Get().Status = %q, want %q", got.Status, StatusRegistered)
+	}
+}
+
+func TestFileCacheMissingFileStartsEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "does-not-exist.json")
+
+	cache, err := NewFileCache(path, 10)
+	if err != nil {
+		t.Fatalf("NewFileCache() error = %v", err)
+	}
+	if _, ok := cache.Get("rdap:example.com"); ok {
+		t.Errorf("Get() on a fresh file cache returned ok = true")
+	}
+}
+
+func TestSingleflightGroupDedupesConcurrentCalls(t *testing.T) {
+	sf := newSingleflightGroup()
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fn := func() *DomainInfo {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		return &DomainInfo{Status: StatusRegistered}
+	}
+
+	results := make(chan *DomainInfo, 2)
+	go func() { results <- sf.Do("example.com", fn) }()
+	<-started // first call is in flight and blocked on release
+
+	go func() { results <- sf.Do("example.com", fn) }()
+	time.Sleep(10 * time.Millisecond) // give the second call a chance to join the in-flight one
+	close(release)
+
+	first, second := <-results, <-results
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fn() was called %d times, want 1", got)
+	}
+	if first != second {
+		t.Errorf("singleflightGroup.Do() returned different results for concurrent callers")
+	}
+}
+
+func TestLookupDomainUsesCache(t *testing.T) {
+	mockRDAPClient := NewMockRDAPClient()
+	mockRDAPClient.SetResponse("example.com", MockResponse("example.com"), nil)
+
+	mockWhoisProvider := NewMockWhoisProvider()
+	mockResolver := NewMockDNSResolver()
+	cache := NewMemoryCache(10)
+
+	info := lookupDomain(mockRDAPClient, mockWhoisProvider, mockResolver, cache, "example.com")
+	if info.Status != StatusRegistered {
+		t.Fatalf("lookupDomain() = %q, want %q", info.Status, StatusRegistered)
+	}
+
+	// Remove the RDAP mock response entirely; a second lookup should still
+	// succeed by reading the cached RDAP tier result instead of issuing a
+	// new RDAP request (which would now return "unexpected request").
+	mockRDAPClient.responses = make(map[string]struct {
+		resp *rdap.Response
+		err  error
+	})
+
+	info = lookupDomain(mockRDAPClient, mockWhoisProvider, mockResolver, cache, "example.com")
+	if info.Status != StatusRegistered {
+		t.Errorf("lookupDomain() after clearing RDAP mock = %q, want %q (cache should have been used)", info.Status, StatusRegistered)
+	}
+}
+
+// slowRDAPClient sleeps on its first call so concurrent duplicate lookups
+// in lookupDomainsMCP have time to queue behind the in-flight singleflight
+// call instead of racing to completion before they can be deduped.
+type slowRDAPClient struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *slowRDAPClient) Do(req *rdap.Request) (*rdap.Response, error) {
+	s.mu.Lock()
+	s.calls++
+	isFirst := s.calls == 1
+	s.mu.Unlock()
+
+	if isFirst {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return MockResponse(req.Query), nil
+}
+
+func TestLookupDomainsMCPDedupesDuplicateDomains(t *testing.T) {
+	rdapClient := &slowRDAPClient{}
+	mockWhoisProvider := NewMockWhoisProvider()
+	mockResolver := NewMockDNSResolver()
+
+	// Use exactly as many duplicate domains as the worker pool has workers,
+	// so every lookup is dispatched in a single concurrent wave and actually
+	// overlaps with the in-flight singleflight call; with more domains than
+	// workers, later waves would start only after the first call already
+	// completed and was evicted, legitimately issuing a second request.
+	const numDuplicates = 10
+	domains := make([]string, numDuplicates)
+	for i := range domains {
+		domains[i] = "example.com"
+	}
+
+	resp, err := lookupDomainsMCP(rdapClient, mockWhoisProvider, mockResolver, nil, MultipleDomainsLookup{Domains: domains})
+	if err != nil {
+		t.Fatalf("lookupDomainsMCP() error = %v, want nil", err)
+	}
+	if resp == nil || len(resp.Content) == 0 {
+		t.Fatalf("lookupDomainsMCP() response missing content")
+	}
+
+	rdapClient.mu.Lock()
+	calls := rdapClient.calls
+	rdapClient.mu.Unlock()
+	if calls != 1 {
+		t.Errorf("RDAP client was called %d times for %d duplicate domains, want 1 (singleflight should dedupe)", calls, numDuplicates)
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	mcp "github.com/metoro-io/mcp-golang"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSResolver abstracts the DNS lookups used for existence probing and the
+// lookup_dns tool, so tests can supply a map-based mock instead of hitting
+// the network.
+type DNSResolver interface {
+	LookupNS(ctx context.Context, domain string) ([]string, error)
+	LookupSOA(ctx context.Context, domain string) (string, error)
+	LookupHost(ctx context.Context, domain string) (v4, v6 []string, err error)
+	LookupMX(ctx context.Context, domain string) ([]string, error)
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+	LookupSRV(ctx context.Context, domain string) ([]string, error)
+	LookupCNAME(ctx context.Context, domain string) (string, error)
+	LookupCAA(ctx context.Context, domain string) ([]CAARecord, error)
+}
+
+// SystemDNSResolver answers lookups using the host's resolver (NS, A/AAAA,
+// MX, TXT, CNAME), falling back to a hand-rolled query for record types the
+// standard library doesn't expose a lookup for (SOA, SRV over a raw name).
+type SystemDNSResolver struct {
+	resolver   *net.Resolver
+	nameserver string
+}
+
+func NewSystemDNSResolver() *SystemDNSResolver {
+	return &SystemDNSResolver{
+		resolver:   net.DefaultResolver,
+		nameserver: systemNameserver(),
+	}
+}
+
+func (s *SystemDNSResolver) LookupNS(ctx context.Context, domain string) ([]string, error) {
+	records, err := s.resolver.LookupNS(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(records))
+	for _, ns := range records {
+		hosts = append(hosts, ns.Host)
+	}
+	return hosts, nil
+}
+
+func (s *SystemDNSResolver) LookupHost(ctx context.Context, domain string) (v4, v6 []string, err error) {
+	addrs, err := s.resolver.LookupIPAddr(ctx, domain)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, addr := range addrs {
+		if ip4 := addr.IP.To4(); ip4 != nil {
+			v4 = append(v4, ip4.String())
+		} else {
+			v6 = append(v6, addr.IP.String())
+		}
+	}
+	return v4, v6, nil
+}
+
+func (s *SystemDNSResolver) LookupMX(ctx context.Context, domain string) ([]string, error) {
+	records, err := s.resolver.LookupMX(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	mxs := make([]string, 0, len(records))
+	for _, mx := range records {
+		mxs = append(mxs, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+	}
+	return mxs, nil
+}
+
+func (s *SystemDNSResolver) LookupTXT(ctx context.Context, domain string) ([]string, error) {
+	return s.resolver.LookupTXT(ctx, domain)
+}
+
+func (s *SystemDNSResolver) LookupCNAME(ctx context.Context, domain string) (string, error) {
+	return s.resolver.LookupCNAME(ctx, domain)
+}
+
+func (s *SystemDNSResolver) LookupSRV(ctx context.Context, domain string) ([]string, error) {
+	answers, err := s.rawQuery(ctx, domain, dnsmessage.TypeSRV)
+	if err != nil {
+		return nil, err
+	}
+	srvs := make([]string, 0, len(answers))
+	for _, rr := range answers {
+		if srv, ok := rr.Body.(*dnsmessage.SRVResource); ok {
+			srvs = append(srvs, fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, srv.Target.String()))
+		}
+	}
+	return srvs, nil
+}
+
+func (s *SystemDNSResolver) LookupSOA(ctx context.Context, domain string) (string, error) {
+	answers, err := s.rawQuery(ctx, domain, dnsmessage.TypeSOA)
+	if err != nil {
+		return "", err
+	}
+	for _, rr := range answers {
+		if soa, ok := rr.Body.(*dnsmessage.SOAResource); ok {
+			return fmt.Sprintf("%s %s %d %d %d %d %d", soa.NS.String(), soa.MBox.String(),
+				soa.Serial, soa.Refresh, soa.Retry, soa.Expire, soa.MinTTL), nil
+		}
+	}
+	return "", fmt.Errorf("no SOA record found for %s", domain)
+}
+
+// typeCAA is the CAA (RFC 8659) record type. dnsmessage doesn't model it
+// directly, so it comes back as an UnknownResource and is decoded by hand.
+const typeCAA dnsmessage.Type = 257
+
+func (s *SystemDNSResolver) LookupCAA(ctx context.Context, domain string) ([]CAARecord, error) {
+	answers, err := s.rawQuery(ctx, domain, typeCAA)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]CAARecord, 0, len(answers))
+	for _, rr := range answers {
+		unknown, ok := rr.Body.(*dnsmessage.UnknownResource)
+		if !ok || unknown.Type != typeCAA {
+			continue
+		}
+		rec, err := parseCAARecord(unknown.Data)
+		if err != nil {
+			log.Printf("failed to parse CAA record for %s: %v", domain, err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// parseCAARecord decodes the CAA RDATA wire format from RFC 8659 section 4:
+// a one-byte flags field, a one-byte tag length, the tag, then the value.
+func parseCAARecord(data []byte) (CAARecord, error) {
+	if len(data) < 2 {
+		return CAARecord{}, fmt.Errorf("CAA record too short: %d bytes", len(data))
+	}
+	flags := data[0]
+	tagLen := int(data[1])
+	if len(data) < 2+tagLen {
+		return CAARecord{}, fmt.Errorf("CAA tag length %d exceeds record length %d", tagLen, len(data))
+	}
+	return CAARecord{
+		Critical: flags&0x80 != 0,
+		Tag:      string(data[2 : 2+tagLen]),
+		Value:    string(data[2+tagLen:]),
+	}, nil
+}
+
+// rawQuery sends a single recursive DNS query over UDP to the system
+// resolver and returns the answer section. The standard library's
+// net.Resolver has no generic query method, so record types it doesn't
+// wrap directly (SOA, SRV against an arbitrary name) are fetched this way.
+func (s *SystemDNSResolver) rawQuery(ctx context.Context, domain string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	name, err := dnsmessage.NewName(dnsName(domain))
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain name %q: %w", domain, err)
+	}
+
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Intn(1 << 16)), RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: name, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build DNS query: %w", err)
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", s.nameserver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach nameserver %s: %w", s.nameserver, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+
+	if _, err := conn.Write(packed); err != nil {
+		return nil, fmt.Errorf("failed to send DNS query: %w", err)
+	}
+
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DNS response: %w", err)
+	}
+
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf[:n]); err != nil {
+		return nil, fmt.Errorf("failed to parse DNS response: %w", err)
+	}
+
+	return resp.Answers, nil
+}
+
+// dnsName normalizes a domain into the fully-qualified, dot-terminated
+// form dnsmessage.NewName expects.
+func dnsName(domain string) string {
+	if strings.HasSuffix(domain, ".") {
+		return domain
+	}
+	return domain + "."
+}
+
+// systemNameserver reads the first "nameserver" entry from /etc/resolv.conf,
+// falling back to a public resolver if none can be found.
+func systemNameserver() string {
+	data, err := os.ReadFile("/etc/resolv.conf")
+	if err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[0] == "nameserver" {
+				return net.JoinHostPort(fields[1], "53")
+			}
+		}
+	}
+	return "8.8.8.8:53"
+}
+
+// dnsExists reports whether a domain has any DNS presence at all (NS
+// delegation, or a SOA/A/AAAA record at the apex), which is a reliable
+// registration signal even for ccTLDs that don't run an RDAP server.
+func dnsExists(ctx context.Context, resolver DNSResolver, domain string) bool {
+	if ns, err := resolver.LookupNS(ctx, domain); err == nil && len(ns) > 0 {
+		return true
+	}
+	if _, err := resolver.LookupSOA(ctx, domain); err == nil {
+		return true
+	}
+	if v4, v6, err := resolver.LookupHost(ctx, domain); err == nil && (len(v4) > 0 || len(v6) > 0) {
+		return true
+	}
+	return false
+}
+
+func lookupWithDNS(resolver DNSResolver, domain string) *DomainInfo {
+	log.Printf("Performing DNS existence probe for: %s", domain)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !dnsExists(ctx, resolver, domain) {
+		log.Printf("DNS probe found no records for %s", domain)
+		return nil
+	}
+
+	ns, _ := resolver.LookupNS(ctx, domain)
+	info := &DomainInfo{
+		Status:      StatusRegistered,
+		Source:      "dns",
+		Nameservers: ns,
+	}
+	log.Printf("DNS probe result for %s: %s", domain, info.Status)
+	return info
+}
+
+type DNSRecordLookup struct {
+	Domain string   `json:"domain" jsonschema:"required,description=The domain name to query (e.g., foo.bar)"`
+	Types  []string `json:"types" jsonschema:"required,description=Record types to query, e.g. [\"A\", \"AAAA\", \"MX\", \"NS\", \"TXT\", \"SRV\", \"CNAME\", \"SOA\"]"`
+}
+
+func lookupDNSRecords(resolver DNSResolver, domain string, types []string) map[string]interface{} {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	records := make(map[string]interface{}, len(types))
+	for _, recordType := range types {
+		switch strings.ToUpper(recordType) {
+		case "A":
+			v4, _, err := resolver.LookupHost(ctx, domain)
+			records["A"] = recordOrError(v4, err)
+		case "AAAA":
+			_, v6, err := resolver.LookupHost(ctx, domain)
+			records["AAAA"] = recordOrError(v6, err)
+		case "MX":
+			mx, err := resolver.LookupMX(ctx, domain)
+			records["MX"] = recordOrError(mx, err)
+		case "NS":
+			ns, err := resolver.LookupNS(ctx, domain)
+			records["NS"] = recordOrError(ns, err)
+		case "TXT":
+			txt, err := resolver.LookupTXT(ctx, domain)
+			records["TXT"] = recordOrError(txt, err)
+		case "SRV":
+			srv, err := resolver.LookupSRV(ctx, domain)
+			records["SRV"] = recordOrError(srv, err)
+		case "CNAME":
+			cname, err := resolver.LookupCNAME(ctx, domain)
+			if err != nil {
+				records["CNAME"] = map[string]string{"error": err.Error()}
+			} else {
+				records["CNAME"] = cname
+			}
+		case "SOA":
+			soa, err := resolver.LookupSOA(ctx, domain)
+			if err != nil {
+				records["SOA"] = map[string]string{"error": err.Error()}
+			} else {
+				records["SOA"] = soa
+			}
+		default:
+			records[recordType] = map[string]string{"error": "unsupported record type"}
+		}
+	}
+	return records
+}
+
+func recordOrError(records []string, err error) interface{} {
+	if err != nil {
+		return map[string]string{"error": err.Error()}
+	}
+	return records
+}
+
+func lookupDNSMCP(resolver DNSResolver, args DNSRecordLookup) (*mcp.ToolResponse, error) {
+	log.Printf("Received DNS lookup request for %s, types: %v", args.Domain, args.Types)
+
+	records := lookupDNSRecords(resolver, args.Domain, args.Types)
+
+	jsonBytes, err := json.Marshal(records)
+	if err != nil {
+		log.Printf("Error marshalling DNS result to JSON: %v", err)
+		errorMsg := fmt.Sprintf("Error formatting DNS result for %s", args.Domain)
+		return mcp.NewToolResponse(mcp.NewTextContent(errorMsg)), nil
+	}
+
+	jsonString := string(jsonBytes)
+	log.Printf("Responding with JSON string: %s", jsonString)
+
+	return mcp.NewToolResponse(mcp.NewTextContent(jsonString)), nil
+}
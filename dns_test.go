@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var _ DNSResolver = (*MockDNSResolver)(nil)
+
+// MockDNSResolver is a map-based DNSResolver for tests. Any lookup not
+// explicitly set via the Set* helpers fails with an "unexpected" error, the
+// same convention MockIPProvider uses, so unconfigured tiers fall through
+// cleanly rather than panicking.
+type MockDNSResolver struct {
+	ns    map[string][]string
+	nsErr map[string]error
+
+	soa    map[string]string
+	soaErr map[string]error
+
+	v4, v6  map[string][]string
+	hostErr map[string]error
+
+	caa    map[string][]CAARecord
+	caaErr map[string]error
+}
+
+func NewMockDNSResolver() *MockDNSResolver {
+	return &MockDNSResolver{
+		ns:      make(map[string][]string),
+		nsErr:   make(map[string]error),
+		soa:     make(map[string]string),
+		soaErr:  make(map[string]error),
+		v4:      make(map[string][]string),
+		v6:      make(map[string][]string),
+		hostErr: make(map[string]error),
+		caa:     make(map[string][]CAARecord),
+		caaErr:  make(map[string]error),
+	}
+}
+
+func (m *MockDNSResolver) SetNS(domain string, ns []string, err error) {
+	m.ns[domain] = ns
+	m.nsErr[domain] = err
+}
+
+func (m *MockDNSResolver) SetSOA(domain string, soa string, err error) {
+	m.soa[domain] = soa
+	m.soaErr[domain] = err
+}
+
+func (m *MockDNSResolver) SetHost(domain string, v4, v6 []string, err error) {
+	m.v4[domain] = v4
+	m.v6[domain] = v6
+	m.hostErr[domain] = err
+}
+
+func (m *MockDNSResolver) SetCAA(domain string, records []CAARecord, err error) {
+	m.caa[domain] = records
+	m.caaErr[domain] = err
+}
+
+func (m *MockDNSResolver) LookupNS(ctx context.Context, domain string) ([]string, error) {
+	if ns, ok := m.ns[domain]; ok {
+		return ns, m.nsErr[domain]
+	}
+	return nil, errors.New("unexpected domain in mock DNS resolver LookupNS")
+}
+
+func (m *MockDNSResolver) LookupSOA(ctx context.Context, domain string) (string, error) {
+	if soa, ok := m.soa[domain]; ok {
+		return soa, m.soaErr[domain]
+	}
+	return "", errors.New("unexpected domain in mock DNS resolver LookupSOA")
+}
+
+func (m *MockDNSResolver) LookupHost(ctx context.Context, domain string) (v4, v6 []string, err error) {
+	if _, ok := m.v4[domain]; ok {
+		return m.v4[domain], m.v6[domain], m.hostErr[domain]
+	}
+	return nil, nil, errors.New("unexpected domain in mock DNS resolver LookupHost")
+}
+
+func (m *MockDNSResolver) LookupMX(ctx context.Context, domain string) ([]string, error) {
+	return nil, errors.New("unexpected domain in mock DNS resolver LookupMX")
+}
+
+func (m *MockDNSResolver) LookupTXT(ctx context.Context, domain string) ([]string, error) {
+	return nil, errors.New("unexpected domain in mock DNS resolver LookupTXT")
+}
+
+func (m *MockDNSResolver) LookupSRV(ctx context.Context, domain string) ([]string, error) {
+	return nil, errors.New("unexpected domain in mock DNS resolver LookupSRV")
+}
+
+func (m *MockDNSResolver) LookupCNAME(ctx context.Context, domain string) (string, error) {
+	return "", errors.New("unexpected domain in mock DNS resolver LookupCNAME")
+}
+
+func (m *MockDNSResolver) LookupCAA(ctx context.Context, domain string) ([]CAARecord, error) {
+	if records, ok := m.caa[domain]; ok {
+		return records, m.caaErr[domain]
+	}
+	return nil, errors.New("unexpected domain in mock DNS resolver LookupCAA")
+}
+
+func TestDNSExists(t *testing.T) {
+	tests := []struct {
+		name     string
+		domain   string
+		setup    func(*MockDNSResolver)
+		expected bool
+	}{
+		{
+			name:   "has NS records",
+			domain: "example.com",
+			setup: func(m *MockDNSResolver) {
+				m.SetNS("example.com", []string{"ns1.example.com"}, nil)
+				m.SetSOA("example.com", "", errors.New("no soa"))
+				m.SetHost("example.com", nil, nil, errors.New("no host"))
+			},
+			expected: true,
+		},
+		{
+			name:   "no NS but has SOA",
+			domain: "example.net",
+			setup: func(m *MockDNSResolver) {
+				m.SetNS("example.net", nil, errors.New("no ns"))
+				m.SetSOA("example.net", "ns1.example.net admin.example.net 1 2 3 4 5", nil)
+				m.SetHost("example.net", nil, nil, errors.New("no host"))
+			},
+			expected: true,
+		},
+		{
+			name:   "no NS or SOA but has A record",
+			domain: "example.org",
+			setup: func(m *MockDNSResolver) {
+				m.SetNS("example.org", nil, errors.New("no ns"))
+				m.SetSOA("example.org", "", errors.New("no soa"))
+				m.SetHost("example.org", []string{"192.0.2.1"}, nil, nil)
+			},
+			expected: true,
+		},
+		{
+			name:   "nothing resolves",
+			domain: "nxdomain.invalid",
+			setup: func(m *MockDNSResolver) {
+				m.SetNS("nxdomain.invalid", nil, errors.New("no ns"))
+				m.SetSOA("nxdomain.invalid", "", errors.New("no soa"))
+				m.SetHost("nxdomain.invalid", nil, nil, errors.New("no host"))
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := NewMockDNSResolver()
+			tc.setup(resolver)
+
+			if got := dnsExists(context.Background(), resolver, tc.domain); got != tc.expected {
+				t.Errorf("dnsExists(%q) = %v, want %v", tc.domain, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLookupWithDNS(t *testing.T) {
+	resolver := NewMockDNSResolver()
+	resolver.SetNS("registered.example", []string{"ns1.registered.example", "ns2.registered.example"}, nil)
+	resolver.SetSOA("registered.example", "", errors.New("no soa"))
+	resolver.SetHost("registered.example", nil, nil, errors.New("no host"))
+
+	resolver.SetNS("unregistered.example", nil, errors.New("no ns"))
+	resolver.SetSOA("unregistered.example", "", errors.New("no soa"))
+	resolver.SetHost("unregistered.example", nil, nil, errors.New("no host"))
+
+	info := lookupWithDNS(resolver, "registered.example")
+	if info == nil {
+		t.Fatalf("lookupWithDNS() = nil, want non-nil DomainInfo")
+	}
+	if info.Status != StatusRegistered {
+		t.Errorf("lookupWithDNS().Status = %q, want %q", info.Status, StatusRegistered)
+	}
+	if info.Source != "dns" {
+		t.Errorf("lookupWithDNS().Source = %q, want %q", info.Source, "dns")
+	}
+	if len(info.Nameservers) != 2 {
+		t.Errorf("lookupWithDNS().Nameservers = %v, want 2 entries", info.Nameservers)
+	}
+
+	if info := lookupWithDNS(resolver, "unregistered.example"); info != nil {
+		t.Errorf("lookupWithDNS() = %+v, want nil", info)
+	}
+}
+
+func TestLookupDNSRecords(t *testing.T) {
+	resolver := NewMockDNSResolver()
+	resolver.SetNS("example.com", []string{"ns1.example.com"}, nil)
+	resolver.SetHost("example.com", []string{"192.0.2.1"}, []string{"2001:db8::1"}, nil)
+
+	records := lookupDNSRecords(resolver, "example.com", []string{"NS", "A", "AAAA", "MX", "BOGUS"})
+
+	ns, ok := records["NS"].([]string)
+	if !ok || len(ns) != 1 || ns[0] != "ns1.example.com" {
+		t.Errorf("records[\"NS\"] = %v, want [ns1.example.com]", records["NS"])
+	}
+	a, ok := records["A"].([]string)
+	if !ok || len(a) != 1 || a[0] != "192.0.2.1" {
+		t.Errorf("records[\"A\"] = %v, want [192.0.2.1]", records["A"])
+	}
+	if _, ok := records["MX"].(map[string]string); !ok {
+		t.Errorf("records[\"MX\"] = %v, want an error map since MX wasn't mocked", records["MX"])
+	}
+	if _, ok := records["BOGUS"].(map[string]string); !ok {
+		t.Errorf("records[\"BOGUS\"] = %v, want an unsupported-type error map", records["BOGUS"])
+	}
+}
+
+func TestLookupDNSMCP(t *testing.T) {
+	resolver := NewMockDNSResolver()
+	resolver.SetNS("example.com", []string{"ns1.example.com"}, nil)
+
+	resp, err := lookupDNSMCP(resolver, DNSRecordLookup{Domain: "example.com", Types: []string{"NS"}})
+	if err != nil {
+		t.Errorf("lookupDNSMCP() error = %v, want nil", err)
+	}
+	if resp == nil || len(resp.Content) == 0 {
+		t.Fatalf("lookupDNSMCP() response missing content")
+	}
+}
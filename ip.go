@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	mcp "github.com/metoro-io/mcp-golang"
+	"github.com/openrdap/rdap"
+	"github.com/shlin168/go-whois/whois"
+)
+
+// ipWhoisServerToRIR maps a WHOIS server host back to the RIR name it
+// belongs to, so WHOIS-sourced results can report which RIR answered.
+var ipWhoisServerToRIR = func() map[string]string {
+	m := make(map[string]string, len(whois.DefaultIPWhoisServerMap))
+	for rir, host := range whois.DefaultIPWhoisServerMap {
+		m[host] = rir
+	}
+	return m
+}()
+
+// IPInfo is the structured result returned for an IP address or CIDR block
+// lookup, parsed from either an RDAP IPNetwork object or a WHOIS response
+// from one of the regional internet registries.
+type IPInfo struct {
+	Status     string `json:"status"`
+	CIDR       string `json:"cidr,omitempty"`
+	RIR        string `json:"rir,omitempty"`
+	Country    string `json:"country,omitempty"`
+	ASN        string `json:"asn,omitempty"`
+	Netname    string `json:"netname,omitempty"`
+	AbuseEmail string `json:"abuse_email,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+type IPProvider interface {
+	Query(ctx context.Context, ipOrCIDR string) (*IPInfo, error)
+}
+
+type WhoisIPClient struct {
+	client *whois.Client
+}
+
+func NewWhoisIPClient() (IPProvider, error) {
+	c, err := whois.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("error creating real WHOIS IP client: %w", err)
+	}
+	return &WhoisIPClient{client: c}, nil
+}
+
+func (g *WhoisIPClient) Query(ctx context.Context, ipOrCIDR string) (*IPInfo, error) {
+	queryIP := ipOrCIDR
+	if host, _, err := net.ParseCIDR(ipOrCIDR); err == nil {
+		queryIP = host.String()
+	}
+
+	result, err := g.client.QueryIP(ctx, queryIP)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &IPInfo{
+		Status: StatusRegistered,
+		Source: "whois",
+		RIR:    ipWhoisServerToRIR[result.WhoisServer],
+	}
+
+	if result.ParsedWhois != nil && len(result.ParsedWhois.Networks) > 0 {
+		network := result.ParsedWhois.Networks[0]
+		if network.Range != nil && len(network.Range.CIDR) > 0 {
+			info.CIDR = network.Range.CIDR[0]
+		}
+		info.Country = network.Country
+		info.ASN = network.OriginAS
+		info.Netname = network.Netname
+		if len(network.AbuseMailbox) > 0 {
+			info.AbuseEmail = network.AbuseMailbox[0]
+		} else if len(network.Email) > 0 {
+			info.AbuseEmail = network.Email[0]
+		}
+	}
+
+	return info, nil
+}
+
+// cidrFromRange derives a CIDR notation string from an RDAP IPNetwork's
+// start/end address pair. RIR allocations are almost always aligned to a
+// prefix boundary; if the range doesn't reduce cleanly to one, the raw
+// start-end range is returned instead of a misleading CIDR.
+func cidrFromRange(start, end net.IP) string {
+	start4, end4 := start.To4(), end.To4()
+	if start4 != nil && end4 != nil {
+		start, end = start4, end4
+	} else {
+		start, end = start.To16(), end.To16()
+	}
+	if start == nil || end == nil || len(start) != len(end) {
+		return ""
+	}
+
+	bits := len(start) * 8
+	prefixLen := 0
+	for i := 0; i < bits; i++ {
+		byteIdx, bitIdx := i/8, 7-uint(i%8)
+		if (start[byteIdx]>>bitIdx)&1 != (end[byteIdx]>>bitIdx)&1 {
+			break
+		}
+		prefixLen++
+	}
+
+	mask := net.CIDRMask(prefixLen, bits)
+	if !start.Mask(mask).Equal(start) {
+		return fmt.Sprintf("%s-%s", start, end)
+	}
+	return fmt.Sprintf("%s/%d", start, prefixLen)
+}
+
+func abuseEmailFromEntities(entities []rdap.Entity) string {
+	for _, e := range entities {
+		for _, role := range e.Roles {
+			if strings.EqualFold(role, "abuse") && e.VCard != nil {
+				if email := e.VCard.Email(); email != "" {
+					return email
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func ipInfoFromRDAP(network *rdap.IPNetwork) *IPInfo {
+	info := &IPInfo{
+		Status:     StatusRegistered,
+		Source:     "rdap",
+		Country:    network.Country,
+		Netname:    network.Name,
+		AbuseEmail: abuseEmailFromEntities(network.Entities),
+	}
+
+	start := net.ParseIP(network.StartAddress)
+	end := net.ParseIP(network.EndAddress)
+	if start != nil && end != nil {
+		info.CIDR = cidrFromRange(start, end)
+	}
+
+	return info
+}
+
+func parseIPRequest(ipOrCIDR string) (*rdap.Request, error) {
+	if ip, ipnet, err := net.ParseCIDR(ipOrCIDR); err == nil {
+		ipnet.IP = ip
+		return rdap.NewIPNetRequest(ipnet), nil
+	}
+	if ip := net.ParseIP(ipOrCIDR); ip != nil {
+		return rdap.NewIPRequest(ip), nil
+	}
+	return nil, fmt.Errorf("invalid IP address or CIDR block: %s", ipOrCIDR)
+}
+
+func lookupIPWithWhois(ipProvider IPProvider, ipOrCIDR string) *IPInfo {
+	log.Printf("Performing WHOIS lookup for IP/CIDR: %s", ipOrCIDR)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := ipProvider.Query(ctx, ipOrCIDR)
+	if err != nil {
+		log.Printf("WHOIS IP lookup error for %s: %v", ipOrCIDR, err)
+		return &IPInfo{Status: StatusUnknown}
+	}
+
+	log.Printf("WHOIS IP lookup result for %s: %+v", ipOrCIDR, info)
+	return info
+}
+
+func lookupIP(rdapClient RDAPClient, ipProvider IPProvider, ipOrCIDR string) *IPInfo {
+	log.Printf("Performing RDAP lookup for IP/CIDR: %s", ipOrCIDR)
+
+	req, err := parseIPRequest(ipOrCIDR)
+	if err != nil {
+		log.Printf("%v", err)
+		return &IPInfo{Status: StatusUnknown}
+	}
+
+	resp, err := rdapClient.Do(req)
+
+	var info *IPInfo
+	if err != nil {
+		log.Printf("RDAP IP lookup error for %s: %v", ipOrCIDR, err)
+	} else if resp != nil {
+		if network, ok := (*resp).Object.(*rdap.IPNetwork); ok {
+			info = ipInfoFromRDAP(network)
+		} else {
+			log.Printf("RDAP IP lookup for %s succeeded but response object was not *rdap.IPNetwork", ipOrCIDR)
+		}
+	}
+
+	if info == nil {
+		log.Printf("RDAP status for %s is unknown, attempting WHOIS fallback lookup.", ipOrCIDR)
+		info = lookupIPWithWhois(ipProvider, ipOrCIDR)
+	}
+
+	log.Printf("Final IP lookup result for %s: %+v", ipOrCIDR, info)
+	return info
+}
+
+type SingleIPLookup struct {
+	IP string `json:"ip" jsonschema:"required,description=The IP address or CIDR block to look up (e.g., 8.8.8.8 or 192.0.2.0/24)"`
+}
+
+type MultipleIPsLookup struct {
+	IPs []string `json:"ips" jsonschema:"required,description=A list of IP addresses or CIDR blocks to look up (e.g., [\"8.8.8.8\", \"192.0.2.0/24\"])"`
+}
+
+func lookupIPMCP(rdapClient RDAPClient, ipProvider IPProvider, args SingleIPLookup) (*mcp.ToolResponse, error) {
+	log.Printf("Received single IP lookup request for: %s", args.IP)
+
+	info := lookupIP(rdapClient, ipProvider, args.IP)
+
+	resultMap := map[string]*IPInfo{
+		args.IP: info,
+	}
+
+	jsonBytes, err := json.Marshal(resultMap)
+	if err != nil {
+		log.Printf("Error marshalling single IP result to JSON: %v", err)
+		errorMsg := fmt.Sprintf("Error formatting result for %s", args.IP)
+		return mcp.NewToolResponse(mcp.NewTextContent(errorMsg)), nil
+	}
+
+	jsonString := string(jsonBytes)
+	log.Printf("Responding with JSON string: %s", jsonString)
+
+	return mcp.NewToolResponse(mcp.NewTextContent(jsonString)), nil
+}
+
+func lookupIPsMCP(rdapClient RDAPClient, ipProvider IPProvider, args MultipleIPsLookup) (*mcp.ToolResponse, error) {
+	log.Printf("Received multiple IP lookup request for %d entries: %v", len(args.IPs), args.IPs)
+
+	numIPs := len(args.IPs)
+	if numIPs == 0 {
+		return mcp.NewToolResponse(mcp.NewTextContent("{}")), nil
+	}
+
+	const numWorkers = 10
+
+	tasks := make(chan string, numIPs)
+	resultsChan := make(chan map[string]*IPInfo, numIPs)
+
+	var workerWg sync.WaitGroup
+
+	actualWorkers := minWorkers(numWorkers, numIPs)
+	log.Printf("Starting %d workers for %d IP lookups.", actualWorkers, numIPs)
+	for i := 0; i < actualWorkers; i++ {
+		workerWg.Add(1)
+		go func(workerID int) {
+			defer workerWg.Done()
+			for ipOrCIDR := range tasks {
+				info := lookupIP(rdapClient, ipProvider, ipOrCIDR)
+				resultsChan <- map[string]*IPInfo{ipOrCIDR: info}
+			}
+		}(i)
+	}
+
+	for _, ipOrCIDR := range args.IPs {
+		tasks <- ipOrCIDR
+	}
+	close(tasks)
+
+	workerWg.Wait()
+	close(resultsChan)
+
+	finalResults := make(map[string]*IPInfo, numIPs)
+	for result := range resultsChan {
+		for ipOrCIDR, info := range result {
+			finalResults[ipOrCIDR] = info
+		}
+	}
+
+	jsonBytes, err := json.Marshal(finalResults)
+	if err != nil {
+		log.Printf("Error marshalling multiple IP results to JSON: %v", err)
+		errorMsg := "Error formatting results for multiple IPs"
+		return mcp.NewToolResponse(mcp.NewTextContent(errorMsg)), nil
+	}
+
+	jsonString := string(jsonBytes)
+	log.Printf("Responding with JSON string: %s", jsonString)
+
+	return mcp.NewToolResponse(mcp.NewTextContent(jsonString)), nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/openrdap/rdap"
+)
+
+var _ IPProvider = (*MockIPProvider)(nil)
+
+type MockIPProvider struct {
+	responses map[string]struct {
+		info *IPInfo
+		err  error
+	}
+}
+
+func NewMockIPProvider() *MockIPProvider {
+	return &MockIPProvider{
+		responses: make(map[string]struct {
+			info *IPInfo
+			err  error
+		}),
+	}
+}
+
+func (m *MockIPProvider) SetResponse(ipOrCIDR string, info *IPInfo, err error) {
+	m.responses[ipOrCIDR] = struct {
+		info *IPInfo
+		err  error
+	}{info, err}
+}
+
+func (m *MockIPProvider) Query(ctx context.Context, ipOrCIDR string) (*IPInfo, error) {
+	if res, ok := m.responses[ipOrCIDR]; ok {
+		return res.info, res.err
+	}
+	return nil, errors.New("unexpected IP in mock IP provider")
+}
+
+func MockIPNetworkResponse(country, netname, abuseEmail string) *rdap.Response {
+	entities := []rdap.Entity{}
+	if abuseEmail != "" {
+		entities = append(entities, rdap.Entity{
+			Roles: []string{"abuse"},
+			VCard: mustVCardWithEmail(abuseEmail),
+		})
+	}
+	network := &rdap.IPNetwork{
+		StartAddress: "192.0.2.0",
+		EndAddress:   "192.0.2.255",
+		Country:      country,
+		Name:         netname,
+		Entities:     entities,
+	}
+	return &rdap.Response{Object: network}
+}
+
+func mustVCardWithEmail(email string) *rdap.VCard {
+	blob := []byte(`["vcard", [["version", {}, "text", "4.0"], ["email", {}, "text", "` + email + `"]]]`)
+	vcard, err := rdap.NewVCard(blob)
+	if err != nil {
+		panic(err)
+	}
+	return vcard
+}
+
+func TestCIDRFromRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    string
+		end      string
+		expected string
+	}{
+		{"aligned /24", "192.0.2.0", "192.0.2.255", "192.0.2.0/24"},
+		{"aligned /32", "192.0.2.1", "192.0.2.1", "192.0.2.1/32"},
+		{"unaligned range", "192.0.2.1", "192.0.2.255", "192.0.2.1-192.0.2.255"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := cidrFromRange(net.ParseIP(tc.start), net.ParseIP(tc.end))
+			if got != tc.expected {
+				t.Errorf("cidrFromRange(%q, %q) = %q, want %q", tc.start, tc.end, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLookupIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		ipOrCIDR       string
+		mockRDAPResp   *rdap.Response
+		mockRDAPErr    error
+		mockWhoisInfo  *IPInfo
+		mockWhoisErr   error
+		expectedStatus string
+		expectedSource string
+	}{
+		{
+			name:           "Resolved via RDAP",
+			ipOrCIDR:       "192.0.2.10",
+			mockRDAPResp:   MockIPNetworkResponse("US", "TEST-NET", "abuse@example.com"),
+			mockRDAPErr:    nil,
+			expectedStatus: StatusRegistered,
+			expectedSource: "rdap",
+		},
+		{
+			name:         "RDAP fails, WHOIS finds network",
+			ipOrCIDR:     "198.51.100.10",
+			mockRDAPResp: nil,
+			mockRDAPErr:  errors.New("no RDAP coverage"),
+			mockWhoisInfo: &IPInfo{
+				Status:  StatusRegistered,
+				RIR:     "ARIN",
+				CIDR:    "198.51.100.0/24",
+				Netname: "TEST-NET-2",
+				Source:  "whois",
+			},
+			expectedStatus: StatusRegistered,
+			expectedSource: "whois",
+		},
+		{
+			name:           "RDAP and WHOIS both fail",
+			ipOrCIDR:       "203.0.113.10",
+			mockRDAPResp:   nil,
+			mockRDAPErr:    errors.New("no RDAP coverage"),
+			mockWhoisErr:   errors.New("whois query failed"),
+			expectedStatus: StatusUnknown,
+		},
+		{
+			name:           "Invalid input",
+			ipOrCIDR:       "not-an-ip",
+			expectedStatus: StatusUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDAPClient := NewMockRDAPClient()
+			mockRDAPClient.SetResponse(tc.ipOrCIDR, tc.mockRDAPResp, tc.mockRDAPErr)
+
+			mockIPProvider := NewMockIPProvider()
+			mockIPProvider.SetResponse(tc.ipOrCIDR, tc.mockWhoisInfo, tc.mockWhoisErr)
+
+			info := lookupIP(mockRDAPClient, mockIPProvider, tc.ipOrCIDR)
+			if info.Status != tc.expectedStatus {
+				t.Errorf("lookupIP(%q).Status = %q, want %q", tc.ipOrCIDR, info.Status, tc.expectedStatus)
+			}
+			if tc.expectedSource != "" && info.Source != tc.expectedSource {
+				t.Errorf("lookupIP(%q).Source = %q, want %q", tc.ipOrCIDR, info.Source, tc.expectedSource)
+			}
+		})
+	}
+}
+
+func TestLookupIPMCP(t *testing.T) {
+	mockRDAPClient := NewMockRDAPClient()
+	mockRDAPClient.SetResponse("192.0.2.10", MockIPNetworkResponse("US", "TEST-NET", ""), nil)
+
+	mockIPProvider := NewMockIPProvider()
+
+	args := SingleIPLookup{IP: "192.0.2.10"}
+	resp, err := lookupIPMCP(mockRDAPClient, mockIPProvider, args)
+	if err != nil {
+		t.Errorf("lookupIPMCP() error = %v, want nil", err)
+	}
+	if resp == nil || len(resp.Content) == 0 {
+		t.Fatalf("lookupIPMCP() response missing content")
+	}
+
+	var result map[string]IPInfo
+	if err := json.Unmarshal([]byte(resp.Content[0].TextContent.Text), &result); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if result["192.0.2.10"].Status != StatusRegistered {
+		t.Errorf("lookupIPMCP() status = %q, want %q", result["192.0.2.10"].Status, StatusRegistered)
+	}
+}
+
+func TestLookupIPsMCPEmpty(t *testing.T) {
+	mockRDAPClient := NewMockRDAPClient()
+	mockIPProvider := NewMockIPProvider()
+	args := MultipleIPsLookup{IPs: []string{}}
+
+	resp, err := lookupIPsMCP(mockRDAPClient, mockIPProvider, args)
+	if err != nil {
+		t.Errorf("lookupIPsMCP() with empty IPs error = %v, want nil", err)
+	}
+	if resp == nil || len(resp.Content) == 0 {
+		t.Fatalf("lookupIPsMCP() response missing content")
+	}
+	if resp.Content[0].TextContent.Text != "{}" {
+		t.Errorf("Expected empty JSON object, got %q", resp.Content[0].TextContent.Text)
+	}
+}
@@ -3,9 +3,13 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,12 +17,14 @@ import (
 	mcp_stdio "github.com/metoro-io/mcp-golang/transport/stdio"
 	"github.com/openrdap/rdap"
 	"github.com/shlin168/go-whois/whois"
+	whoisdomain "github.com/shlin168/go-whois/whois/domain"
 )
 
 const (
-	StatusRegistered = "registered"
-	StatusAvailable  = "available"
-	StatusUnknown    = "unknown"
+	StatusRegistered  = "registered"
+	StatusAvailable   = "available"
+	StatusUnknown     = "unknown"
+	StatusRateLimited = "rate_limited"
 )
 
 type RDAPClient interface {
@@ -28,6 +34,8 @@ type RDAPClient interface {
 type WhoisResult struct {
 	IsAvailable *bool
 	RawText     string
+	Parsed      *whoisdomain.ParsedWhois
+	RespType    string
 }
 
 type WhoisProvider interface {
@@ -48,87 +56,348 @@ func NewWhoisClient() (WhoisProvider, error) {
 
 func (g *WhoisClient) Query(ctx context.Context, domain string) (*WhoisResult, error) {
 	result, err := g.client.Query(ctx, domain)
+
+	wr := &WhoisResult{RespType: classifyWhoisRespType(err)}
+	if result != nil {
+		wr.IsAvailable = result.IsAvailable
+		wr.RawText = result.RawText
+		wr.Parsed = result.ParsedWhois
+	}
+
+	return wr, err
+}
+
+// classifyWhoisRespType maps a go-whois query error to one of its
+// RespType* constants, so callers can surface the finer-grained failure
+// mode instead of collapsing everything to a generic error.
+func classifyWhoisRespType(err error) string {
+	switch {
+	case err == nil:
+		return whois.RespTypeFound
+	case errors.Is(err, whois.ErrDomainIPNotFound):
+		return whois.RespTypeNotFound
+	case whois.IsParsePanicErr(err):
+		return whois.RespTypeParseError
+	case errors.Is(err, whois.ErrTimeout):
+		return whois.RespTypeTimeout
+	default:
+		return whois.RespTypeError
+	}
+}
+
+// DomainInfo is the structured result returned for a domain lookup,
+// parsed from either an RDAP Domain object or a WHOIS response.
+type DomainInfo struct {
+	Status            string   `json:"status"`
+	Registrar         string   `json:"registrar,omitempty"`
+	CreatedDate       string   `json:"created_date,omitempty"`
+	UpdatedDate       string   `json:"updated_date,omitempty"`
+	ExpirationDate    string   `json:"expiration_date,omitempty"`
+	Nameservers       []string `json:"nameservers,omitempty"`
+	StatusCodes       []string `json:"status_codes,omitempty"`
+	DNSSEC            bool     `json:"dnssec,omitempty"`
+	AbuseEmail        string   `json:"abuse_email,omitempty"`
+	Source            string   `json:"source,omitempty"`
+	WhoisResponseType string   `json:"whois_response_type,omitempty"`
+	RetryAfter        string   `json:"retry_after,omitempty"`
+	// CacheMaxAge is the Cache-Control max-age hint from the RDAP HTTP
+	// response, if any. It's an internal cacheTTL input, not part of the
+	// lookup result, so it's never serialized.
+	CacheMaxAge time.Duration `json:"-"`
+}
+
+// domainInfoFromRDAP extracts a DomainInfo from an RDAP Domain object.
+func domainInfoFromRDAP(domain *rdap.Domain) *DomainInfo {
+	info := &DomainInfo{
+		Status:      StatusRegistered,
+		Source:      "rdap",
+		StatusCodes: domain.Status,
+		AbuseEmail:  abuseEmailFromEntities(domain.Entities),
+	}
+
+	for _, ns := range domain.Nameservers {
+		if ns.LDHName != "" {
+			info.Nameservers = append(info.Nameservers, ns.LDHName)
+		}
+	}
+
+	for _, ev := range domain.Events {
+		switch ev.Action {
+		case "registration":
+			info.CreatedDate = ev.Date
+		case "last changed":
+			info.UpdatedDate = ev.Date
+		case "expiration":
+			info.ExpirationDate = ev.Date
+		}
+	}
+
+	if domain.SecureDNS != nil && domain.SecureDNS.DelegationSigned != nil {
+		info.DNSSEC = *domain.SecureDNS.DelegationSigned
+	}
+
+	for _, e := range domain.Entities {
+		for _, role := range e.Roles {
+			if role == "registrar" && e.VCard != nil {
+				if name := e.VCard.Name(); name != "" {
+					info.Registrar = name
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// domainInfoFromWhois extracts a DomainInfo from a parsed WHOIS response.
+func domainInfoFromWhois(result *WhoisResult) *DomainInfo {
+	info := &DomainInfo{
+		Status:            StatusUnknown,
+		Source:            "whois",
+		WhoisResponseType: result.RespType,
+	}
+
+	if result.IsAvailable != nil {
+		if *result.IsAvailable {
+			info.Status = StatusAvailable
+		} else {
+			info.Status = StatusRegistered
+		}
+	} else if result.RespType == whois.RespTypeNotFound {
+		// go-whois's own "not found" signal is authoritative even when the
+		// registry's whois-server-list has no AvailPtn configured to
+		// populate IsAvailable: the raw body below is a "No match for ..."
+		// message, not evidence of registration, so don't fall into the
+		// raw-text heuristic and infer the opposite of reality.
+		info.Status = StatusAvailable
+	} else if result.RawText != "" {
+		log.Printf("WHOIS raw text found but availability unclear, inferring as registered")
+		info.Status = StatusRegistered
+	}
+
+	if result.Parsed != nil {
+		info.Nameservers = result.Parsed.NameServers
+		info.StatusCodes = result.Parsed.Statuses
+		info.CreatedDate = result.Parsed.CreatedDate
+		info.UpdatedDate = result.Parsed.UpdatedDate
+		info.ExpirationDate = result.Parsed.ExpiredDate
+		info.DNSSEC = strings.EqualFold(result.Parsed.Dnssec, "signed")
+		if result.Parsed.Registrar != nil {
+			info.Registrar = result.Parsed.Registrar.Name
+			info.AbuseEmail = result.Parsed.Registrar.AbuseContactEmail
+		}
+	}
+
+	return info
+}
+
+// filterDomainInfoFields reduces a DomainInfo down to the requested subset
+// of JSON fields. An empty fields list returns the full object.
+func filterDomainInfoFields(info *DomainInfo, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return info, nil
+	}
+
+	raw, err := json.Marshal(info)
 	if err != nil {
 		return nil, err
 	}
 
-	return &WhoisResult{
-		IsAvailable: result.IsAvailable,
-		RawText:     result.RawText,
-	}, nil
+	full := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &full); err != nil {
+		return nil, err
+	}
+
+	filtered := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if v, ok := full[field]; ok {
+			filtered[field] = v
+		}
+	}
+	return filtered, nil
 }
 
 type SingleDomainLookup struct {
-	Domain string `json:"domain" jsonschema:"required,description=The domain name to look up (e.g., foo.bar)"`
+	Domain string   `json:"domain" jsonschema:"required,description=The domain name to look up (e.g., foo.bar)"`
+	Fields []string `json:"fields,omitempty" jsonschema:"description=Optional subset of result fields to return (e.g., [\"status\", \"registrar\"]). Omit to return all fields."`
 }
 
 type MultipleDomainsLookup struct {
 	Domains []string `json:"domains" jsonschema:"required,description=A list of domain names to look up (e.g., [\"foo.bar\", \"example.com\"])"`
+	Fields  []string `json:"fields,omitempty" jsonschema:"description=Optional subset of result fields to return (e.g., [\"status\", \"registrar\"]). Omit to return all fields."`
 }
 
-func lookupWithWhois(whoisClient WhoisProvider, domain string) string {
+func lookupWithWhois(whoisClient WhoisProvider, domain string) *DomainInfo {
 	log.Printf("Performing WHOIS lookup for: %s", domain)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	whoisResult, err := whoisClient.Query(ctx, domain)
+	if errors.Is(err, ErrRateLimited) {
+		log.Printf("WHOIS lookup for %s was rate limited: %v", domain, err)
+		return &DomainInfo{Status: StatusRateLimited, Source: "whois"}
+	}
+	if err != nil && whoisResult == nil {
+		log.Printf("WHOIS lookup error for %s: %v", domain, err)
+		return &DomainInfo{Status: StatusUnknown, Source: "whois", WhoisResponseType: classifyWhoisRespType(err)}
+	}
 	if err != nil {
 		log.Printf("WHOIS lookup error for %s: %v", domain, err)
-		return StatusUnknown
 	}
 
-	status := StatusUnknown
-	if whoisResult.IsAvailable != nil {
-		if *whoisResult.IsAvailable {
-			status = StatusAvailable
-		} else {
-			status = StatusRegistered
+	info := domainInfoFromWhois(whoisResult)
+	log.Printf("WHOIS lookup result for %s: %s", domain, info.Status)
+	return info
+}
+
+// domainLookupOrder is the tier order lookupDomain tries, stopping at the
+// first tier that resolves the domain. DNS sits between RDAP and WHOIS
+// since it's cheaper than a WHOIS query and catches ccTLDs that are
+// registered but don't run an RDAP server. Overridable via the
+// -lookup-order flag; see parseLookupOrder.
+var domainLookupOrder = []string{"rdap", "dns", "whois"}
+
+// validDomainLookupTiers are the tier names lookupTier knows how to run.
+var validDomainLookupTiers = map[string]bool{"rdap": true, "dns": true, "whois": true}
+
+// parseLookupOrder parses a comma-separated tier list (e.g. "rdap,dns,whois")
+// into the slice form domainLookupOrder expects, validating each tier name
+// against validDomainLookupTiers.
+func parseLookupOrder(s string) ([]string, error) {
+	var order []string
+	for _, tier := range strings.Split(s, ",") {
+		tier = strings.TrimSpace(tier)
+		if tier == "" {
+			continue
 		}
-	} else if whoisResult.RawText != "" {
-		log.Printf("WHOIS raw text found for %s but availability unclear, inferring as registered", domain)
-		status = StatusRegistered
+		if !validDomainLookupTiers[tier] {
+			return nil, fmt.Errorf("unknown domain lookup tier %q (valid tiers: rdap, dns, whois)", tier)
+		}
+		order = append(order, tier)
 	}
-
-	log.Printf("WHOIS lookup result for %s: %s", domain, status)
-	return status
+	if len(order) == 0 {
+		return nil, fmt.Errorf("lookup order must name at least one tier")
+	}
+	return order, nil
 }
 
-func lookupDomain(rdapClient RDAPClient, whoisClient WhoisProvider, domain string) string {
+func lookupWithRDAP(rdapClient RDAPClient, domain string) *DomainInfo {
 	log.Printf("Performing RDAP lookup for: %s", domain)
 	req := rdap.NewRequest(rdap.DomainRequest, domain)
 
 	resp, err := rdapClient.Do(req)
-
-	status := StatusUnknown
-
 	if err != nil {
+		var rlErr *rateLimitedRDAPRequestError
+		if errors.As(err, &rlErr) {
+			log.Printf("RDAP lookup for %s was rate limited, retry after %q", domain, rlErr.retryAfter)
+			return &DomainInfo{Status: StatusRateLimited, Source: "rdap", RetryAfter: rlErr.retryAfter}
+		}
+		if errors.Is(err, ErrRateLimited) {
+			log.Printf("RDAP lookup for %s was rate limited: %v", domain, err)
+			return &DomainInfo{Status: StatusRateLimited, Source: "rdap"}
+		}
 		log.Printf("RDAP lookup error for %s: %v", domain, err)
-	} else if resp != nil {
-		if _, ok := (*resp).Object.(*rdap.Domain); ok {
-			status = StatusRegistered
-		} else {
-			log.Printf("RDAP lookup for %s succeeded but response object was not *rdap.Domain", domain)
+		return nil
+	}
+	if resp == nil {
+		return nil
+	}
+	rdapDomain, ok := (*resp).Object.(*rdap.Domain)
+	if !ok {
+		log.Printf("RDAP lookup for %s succeeded but response object was not *rdap.Domain", domain)
+		return nil
+	}
+	info := domainInfoFromRDAP(rdapDomain)
+	if maxAge, ok := rdapCacheControlMaxAge(resp); ok {
+		info.CacheMaxAge = maxAge
+	}
+	return info
+}
+
+// rdapCacheControlMaxAge scans an RDAP response's HTTP attempts for a
+// Cache-Control max-age directive, returning it and whether one was found.
+func rdapCacheControlMaxAge(resp *rdap.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	for _, httpResp := range resp.HTTP {
+		if httpResp == nil || httpResp.Response == nil {
+			continue
+		}
+		for _, directive := range strings.Split(httpResp.Response.Header.Get("Cache-Control"), ",") {
+			name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+			if !found || !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+				continue
+			}
+			if secs, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && secs > 0 {
+				return time.Duration(secs) * time.Second, true
+			}
 		}
 	}
+	return 0, false
+}
 
-	log.Printf("RDAP lookup intermediate result for %s: %s", domain, status)
+// lookupTier runs a single tier of the domainLookupOrder chain, returning
+// nil if that tier doesn't resolve the domain (or doesn't exist).
+func lookupTier(rdapClient RDAPClient, whoisClient WhoisProvider, resolver DNSResolver, tier, domain string) *DomainInfo {
+	switch tier {
+	case "rdap":
+		return lookupWithRDAP(rdapClient, domain)
+	case "dns":
+		return lookupWithDNS(resolver, domain)
+	case "whois":
+		return lookupWithWhois(whoisClient, domain)
+	default:
+		log.Printf("Unknown domain lookup tier %q, skipping", tier)
+		return nil
+	}
+}
+
+func lookupDomain(rdapClient RDAPClient, whoisClient WhoisProvider, resolver DNSResolver, cache Cache, domain string) *DomainInfo {
+	log.Printf("Looking up %s using tier order %v", domain, domainLookupOrder)
+
+	var info *DomainInfo
+	for _, tier := range domainLookupOrder {
+		cacheKey := tier + ":" + domain
+		if cache == nil {
+			info = lookupTier(rdapClient, whoisClient, resolver, tier, domain)
+		} else if cached, ok := cache.Get(cacheKey); ok {
+			log.Printf("Cache hit for %s tier %q", domain, tier)
+			info = cached
+		} else {
+			info = lookupTier(rdapClient, whoisClient, resolver, tier, domain)
+			cache.Set(cacheKey, info, cacheTTL(info))
+		}
 
-	if status == StatusUnknown {
-		log.Printf("RDAP status for %s is unknown, attempting WHOIS fallback lookup.", domain)
-		status = lookupWithWhois(whoisClient, domain)
+		if info != nil {
+			break
+		}
+		log.Printf("Tier %q did not resolve %s, trying next tier.", tier, domain)
+	}
+
+	if info == nil {
+		info = &DomainInfo{Status: StatusUnknown}
 	}
 
-	log.Printf("Final lookup result for %s: %s", domain, status)
-	return status
+	log.Printf("Final lookup result for %s: %s", domain, info.Status)
+	return info
 }
 
-func lookupDomainMCP(rdapClient RDAPClient, whoisClient WhoisProvider, args SingleDomainLookup) (*mcp.ToolResponse, error) {
+func lookupDomainMCP(rdapClient RDAPClient, whoisClient WhoisProvider, resolver DNSResolver, cache Cache, args SingleDomainLookup) (*mcp.ToolResponse, error) {
 	log.Printf("Received single lookup request for domain: %s", args.Domain)
 
-	status := lookupDomain(rdapClient, whoisClient, args.Domain)
+	info := lookupDomain(rdapClient, whoisClient, resolver, cache, args.Domain)
+
+	filtered, err := filterDomainInfoFields(info, args.Fields)
+	if err != nil {
+		log.Printf("Error filtering fields for %s: %v", args.Domain, err)
+		errorMsg := fmt.Sprintf("Error formatting result for %s", args.Domain)
+		return mcp.NewToolResponse(mcp.NewTextContent(errorMsg)), nil
+	}
 
-	resultMap := map[string]string{
-		args.Domain: status,
+	resultMap := map[string]interface{}{
+		args.Domain: filtered,
 	}
 
 	jsonBytes, err := json.Marshal(resultMap)
@@ -144,7 +413,7 @@ func lookupDomainMCP(rdapClient RDAPClient, whoisClient WhoisProvider, args Sing
 	return mcp.NewToolResponse(mcp.NewTextContent(jsonString)), nil
 }
 
-func lookupDomainsMCP(rdapClient RDAPClient, whoisClient WhoisProvider, args MultipleDomainsLookup) (*mcp.ToolResponse, error) {
+func lookupDomainsMCP(rdapClient RDAPClient, whoisClient WhoisProvider, resolver DNSResolver, cache Cache, args MultipleDomainsLookup) (*mcp.ToolResponse, error) {
 	log.Printf("Received multiple lookup request for %d domains: %v", len(args.Domains), args.Domains)
 
 	numDomains := len(args.Domains)
@@ -155,9 +424,10 @@ func lookupDomainsMCP(rdapClient RDAPClient, whoisClient WhoisProvider, args Mul
 	const numWorkers = 10
 
 	tasks := make(chan string, numDomains)
-	resultsChan := make(chan map[string]string, numDomains)
+	resultsChan := make(chan map[string]*DomainInfo, numDomains)
 
 	var workerWg sync.WaitGroup
+	sf := newSingleflightGroup()
 
 	actualWorkers := minWorkers(numWorkers, numDomains)
 	log.Printf("Starting %d workers for %d domains.", actualWorkers, numDomains)
@@ -166,9 +436,10 @@ func lookupDomainsMCP(rdapClient RDAPClient, whoisClient WhoisProvider, args Mul
 		go func(workerID int) {
 			defer workerWg.Done()
 			for domain := range tasks {
-				status := lookupDomain(rdapClient, whoisClient, domain)
-				singleResult := map[string]string{domain: status}
-				resultsChan <- singleResult
+				info := sf.Do(domain, func() *DomainInfo {
+					return lookupDomain(rdapClient, whoisClient, resolver, cache, domain)
+				})
+				resultsChan <- map[string]*DomainInfo{domain: info}
 			}
 		}(i)
 	}
@@ -181,10 +452,15 @@ func lookupDomainsMCP(rdapClient RDAPClient, whoisClient WhoisProvider, args Mul
 	workerWg.Wait()
 	close(resultsChan)
 
-	finalResults := make(map[string]string, numDomains)
+	finalResults := make(map[string]interface{}, numDomains)
 	for result := range resultsChan {
-		for domain, status := range result {
-			finalResults[domain] = status
+		for domain, info := range result {
+			filtered, err := filterDomainInfoFields(info, args.Fields)
+			if err != nil {
+				log.Printf("Error filtering fields for %s: %v", domain, err)
+				filtered = info
+			}
+			finalResults[domain] = filtered
 		}
 	}
 
@@ -209,9 +485,38 @@ func minWorkers(a, b int) int {
 }
 
 func main() {
+	cacheFile := flag.String("cache-file", "", "optional path to a JSON file used to persist the domain lookup cache across restarts; defaults to an in-memory-only cache")
+	cacheCapacity := flag.Int("cache-capacity", 1000, "maximum number of entries kept in the domain lookup cache")
+	rateLimitQPS := flag.Float64("rate-limit-qps", defaultRateLimitQPS, "default requests per second allowed to a single RDAP or WHOIS server host")
+	rateLimitBurst := flag.Int("rate-limit-burst", defaultRateLimitBurst, "default burst size allowed to a single RDAP or WHOIS server host")
+	rateLimitConfigFile := flag.String("rate-limit-config", "", "optional path to a JSON file overriding the default QPS/burst per RDAP or WHOIS server host")
+	lookupOrder := flag.String("lookup-order", strings.Join(domainLookupOrder, ","), "comma-separated domain lookup tier order to try, stopping at the first that resolves (valid tiers: rdap, dns, whois)")
+	flag.Parse()
+
 	log.Println("Starting MCP Server via stdio...")
 
-	rdapClient := &rdap.Client{}
+	order, err := parseLookupOrder(*lookupOrder)
+	if err != nil {
+		log.Fatalf("Error parsing lookup order: %v", err)
+		os.Exit(1)
+	}
+	domainLookupOrder = order
+	log.Printf("Domain lookup tier order: %v", domainLookupOrder)
+
+	var rateLimitConfig *RateLimitConfig
+	if *rateLimitConfigFile != "" {
+		cfg, err := loadRateLimitConfig(*rateLimitConfigFile)
+		if err != nil {
+			log.Fatalf("Error loading rate limit config: %v", err)
+			os.Exit(1)
+		}
+		rateLimitConfig = cfg
+		log.Printf("Loaded rate limit config from %s.", *rateLimitConfigFile)
+	}
+	hostRateLimiter := NewHostRateLimiter(*rateLimitQPS, *rateLimitBurst, rateLimitConfig)
+	log.Printf("Per-host rate limiting enabled (default %g qps, burst %d).", *rateLimitQPS, *rateLimitBurst)
+
+	var rdapClient RDAPClient = NewRateLimitedRDAPClient(&rdap.Client{}, hostRateLimiter)
 	log.Println("Shared RDAP client created.")
 
 	whoisClient, err := NewWhoisClient()
@@ -221,13 +526,44 @@ func main() {
 	}
 	log.Println("Shared WHOIS client created.")
 
+	whoisServerMap, err := whois.NewDomainWhoisServerMap(whois.WhoisServerListURL)
+	if err != nil {
+		log.Fatalf("Error loading WHOIS server list: %v", err)
+		os.Exit(1)
+	}
+	whoisClient = NewRateLimitedWhoisProvider(whoisClient, whoisServerMap, hostRateLimiter)
+
+	ipProvider, err := NewWhoisIPClient()
+	if err != nil {
+		log.Fatalf("Error creating WHOIS IP client: %v", err)
+		os.Exit(1)
+	}
+	log.Println("Shared WHOIS IP client created.")
+
+	resolver := NewSystemDNSResolver()
+	log.Println("Shared DNS resolver created.")
+
+	var cache Cache
+	if *cacheFile != "" {
+		fileCache, err := NewFileCache(*cacheFile, *cacheCapacity)
+		if err != nil {
+			log.Fatalf("Error creating file-backed cache: %v", err)
+			os.Exit(1)
+		}
+		cache = fileCache
+		log.Printf("Using file-backed domain lookup cache at %s (capacity %d).", *cacheFile, *cacheCapacity)
+	} else {
+		cache = NewMemoryCache(*cacheCapacity)
+		log.Printf("Using in-memory domain lookup cache (capacity %d).", *cacheCapacity)
+	}
+
 	server := mcp.NewServer(mcp_stdio.NewStdioServerTransport())
 
 	err = server.RegisterTool(
 		"lookup_domain",
-		`Looks up a single domain name using RDAP (with WHOIS fallback). Returns JSON: {"domain": "status"} ('registered', 'available', or 'unknown')`,
+		`Looks up a single domain name using RDAP, a DNS existence probe, and WHOIS, in that order. Returns JSON: {"domain": {"status": "registered"|"available"|"unknown"|"rate_limited", "registrar", "created_date", "updated_date", "expiration_date", "nameservers", "status_codes", "dnssec", "abuse_email", "source", "whois_response_type", "retry_after"}}. A "rate_limited" status means a per-host outbound rate limit or upstream 429 blocked the lookup; "retry_after" gives a hint in seconds when known. Use "fields" to request a subset.`,
 		func(args SingleDomainLookup) (*mcp.ToolResponse, error) {
-			return lookupDomainMCP(rdapClient, whoisClient, args)
+			return lookupDomainMCP(rdapClient, whoisClient, resolver, cache, args)
 		},
 	)
 	if err != nil {
@@ -238,16 +574,68 @@ func main() {
 
 	err = server.RegisterTool(
 		"lookup_domains",
-		`Looks up multiple domain names using RDAP (with WHOIS fallback). Returns JSON: {"domain1": "status1", ...} ('registered', 'available', or 'unknown')`,
+		`Looks up multiple domain names using RDAP, a DNS existence probe, and WHOIS, in that order. Returns JSON: {"domain1": {...}, ...}, one object per domain shaped like lookup_domain. Use "fields" to request a subset.`,
 		func(args MultipleDomainsLookup) (*mcp.ToolResponse, error) {
-			return lookupDomainsMCP(rdapClient, whoisClient, args)
+			return lookupDomainsMCP(rdapClient, whoisClient, resolver, cache, args)
 		},
 	)
 	if err != nil {
 		log.Fatalf("Error registering lookup_domains tool: %v", err)
 		os.Exit(1)
 	}
-	log.Println("lookup_domains tool registered. MCP Server waiting for requests...")
+	log.Println("lookup_domains tool registered.")
+
+	err = server.RegisterTool(
+		"lookup_dns",
+		`Queries raw DNS records for a domain. Returns JSON: {"A": [...], "AAAA": [...], "MX": [...], "NS": [...], "TXT": [...], "SRV": [...], "CNAME": "...", "SOA": "..."}, keyed by the requested "types". Unsupported types or lookup errors are reported as {"error": "..."} for that key.`,
+		func(args DNSRecordLookup) (*mcp.ToolResponse, error) {
+			return lookupDNSMCP(resolver, args)
+		},
+	)
+	if err != nil {
+		log.Fatalf("Error registering lookup_dns tool: %v", err)
+		os.Exit(1)
+	}
+	log.Println("lookup_dns tool registered.")
+
+	err = server.RegisterTool(
+		"lookup_caa",
+		`Audits CAA records for a domain per RFC 8659, walking up from the FQDN to the first ancestor with any CAA records. Returns JSON: {"domain": "<ancestor where records were found>", "records": [{"critical", "tag", "value"}, ...], "permitted_for_issuer": true|false}. "permitted_for_issuer" is only present if "issuer" was supplied.`,
+		func(args CAALookup) (*mcp.ToolResponse, error) {
+			return lookupCAAMCP(resolver, args)
+		},
+	)
+	if err != nil {
+		log.Fatalf("Error registering lookup_caa tool: %v", err)
+		os.Exit(1)
+	}
+	log.Println("lookup_caa tool registered.")
+
+	err = server.RegisterTool(
+		"lookup_ip",
+		`Looks up a single IP address or CIDR block using RDAP (with WHOIS fallback). Returns JSON: {"ip": {"status": "registered"|"unknown", "cidr", "rir", "country", "asn", "netname", "abuse_email", "source"}}`,
+		func(args SingleIPLookup) (*mcp.ToolResponse, error) {
+			return lookupIPMCP(rdapClient, ipProvider, args)
+		},
+	)
+	if err != nil {
+		log.Fatalf("Error registering lookup_ip tool: %v", err)
+		os.Exit(1)
+	}
+	log.Println("lookup_ip tool registered.")
+
+	err = server.RegisterTool(
+		"lookup_ips",
+		`Looks up multiple IP addresses or CIDR blocks using RDAP (with WHOIS fallback). Returns JSON: {"ip1": {...}, ...}, one object per entry shaped like lookup_ip`,
+		func(args MultipleIPsLookup) (*mcp.ToolResponse, error) {
+			return lookupIPsMCP(rdapClient, ipProvider, args)
+		},
+	)
+	if err != nil {
+		log.Fatalf("Error registering lookup_ips tool: %v", err)
+		os.Exit(1)
+	}
+	log.Println("lookup_ips tool registered. MCP Server waiting for requests...")
 
 	err = server.Serve()
 	if err != nil {
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/openrdap/rdap"
+	"github.com/shlin168/go-whois/whois"
+	whoisdomain "github.com/shlin168/go-whois/whois/domain"
 )
 
 // Verify that MockRDAPClient implements the RDAPClient interface
@@ -130,6 +133,21 @@ func TestLookupWithWhois(t *testing.T) {
 			mockError:      nil,
 			expectedStatus: StatusRegistered,
 		},
+		{
+			// Registries with no AvailPtn configured in the whois-server-list
+			// never populate IsAvailable, but go-whois still surfaces
+			// ErrDomainIPNotFound as an authoritative "not found" signal; the
+			// raw-text heuristic must not override it and infer the opposite.
+			name:   "Not found with no IsAvailable flag",
+			domain: "not-found-domain.tld",
+			mockResult: &WhoisResult{
+				IsAvailable: nil,
+				RawText:     "No match for \"NOT-FOUND-DOMAIN.TLD\"",
+				RespType:    whois.RespTypeNotFound,
+			},
+			mockError:      whois.ErrDomainIPNotFound,
+			expectedStatus: StatusAvailable,
+		},
 	}
 
 	for _, tc := range tests {
@@ -137,9 +155,9 @@ func TestLookupWithWhois(t *testing.T) {
 			mockWhoisProvider := NewMockWhoisProvider()
 			mockWhoisProvider.SetResponse(tc.domain, tc.mockResult, tc.mockError)
 
-			status := lookupWithWhois(mockWhoisProvider, tc.domain)
-			if status != tc.expectedStatus {
-				t.Errorf("lookupWithWhois(%q) = %q, want %q", tc.domain, status, tc.expectedStatus)
+			info := lookupWithWhois(mockWhoisProvider, tc.domain)
+			if info.Status != tc.expectedStatus {
+				t.Errorf("lookupWithWhois(%q) = %q, want %q", tc.domain, info.Status, tc.expectedStatus)
 			}
 		})
 	}
@@ -149,6 +167,269 @@ func boolPtr(b bool) *bool {
 	return &b
 }
 
+func TestParseLookupOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{"default order", "rdap,dns,whois", []string{"rdap", "dns", "whois"}, false},
+		{"reordered and subset", "whois, rdap", []string{"whois", "rdap"}, false},
+		{"single tier", "rdap", []string{"rdap"}, false},
+		{"unknown tier", "rdap,carrier-pigeon", nil, true},
+		{"empty string", "", nil, true},
+		{"only commas", " , ,", nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseLookupOrder(tc.s)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseLookupOrder(%q) error = nil, want an error", tc.s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLookupOrder(%q) error = %v", tc.s, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseLookupOrder(%q) = %v, want %v", tc.s, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClassifyWhoisRespType(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"no error", nil, whois.RespTypeFound},
+		{"domain not found", whois.ErrDomainIPNotFound, whois.RespTypeNotFound},
+		{"wrapped domain not found", fmt.Errorf("query failed: %w", whois.ErrDomainIPNotFound), whois.RespTypeNotFound},
+		{"timeout", whois.ErrTimeout, whois.RespTypeTimeout},
+		{"other error", errors.New("connection reset"), whois.RespTypeError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := classifyWhoisRespType(tc.err); got != tc.want {
+				t.Errorf("classifyWhoisRespType(%v) = %q, want %q", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDomainInfoFromRDAP(t *testing.T) {
+	falseVal := false
+	domain := &rdap.Domain{
+		Status: []string{"client transfer prohibited"},
+		Nameservers: []rdap.Nameserver{
+			{LDHName: "ns1.example.com"},
+			{LDHName: "ns2.example.com"},
+			{},
+		},
+		Events: []rdap.Event{
+			{Action: "registration", Date: "2020-01-01T00:00:00Z"},
+			{Action: "last changed", Date: "2024-01-01T00:00:00Z"},
+			{Action: "expiration", Date: "2030-01-01T00:00:00Z"},
+		},
+		SecureDNS: &rdap.SecureDNS{DelegationSigned: &falseVal},
+		Entities: []rdap.Entity{
+			{Roles: []string{"registrar"}, VCard: mustVCard(`["fn", {}, "text", "Example Registrar, LLC"]`)},
+			{Roles: []string{"abuse"}, VCard: mustVCard(`["email", {}, "text", "abuse@example.com"]`)},
+		},
+	}
+
+	info := domainInfoFromRDAP(domain)
+
+	if info.Status != StatusRegistered {
+		t.Errorf("Status = %q, want %q", info.Status, StatusRegistered)
+	}
+	if info.Source != "rdap" {
+		t.Errorf("Source = %q, want %q", info.Source, "rdap")
+	}
+	if want := []string{"client transfer prohibited"}; !reflect.DeepEqual(info.StatusCodes, want) {
+		t.Errorf("StatusCodes = %v, want %v", info.StatusCodes, want)
+	}
+	if want := []string{"ns1.example.com", "ns2.example.com"}; !reflect.DeepEqual(info.Nameservers, want) {
+		t.Errorf("Nameservers = %v, want %v (nameservers with no LDHName should be skipped)", info.Nameservers, want)
+	}
+	if info.CreatedDate != "2020-01-01T00:00:00Z" {
+		t.Errorf("CreatedDate = %q, want %q", info.CreatedDate, "2020-01-01T00:00:00Z")
+	}
+	if info.UpdatedDate != "2024-01-01T00:00:00Z" {
+		t.Errorf("UpdatedDate = %q, want %q", info.UpdatedDate, "2024-01-01T00:00:00Z")
+	}
+	if info.ExpirationDate != "2030-01-01T00:00:00Z" {
+		t.Errorf("ExpirationDate = %q, want %q", info.ExpirationDate, "2030-01-01T00:00:00Z")
+	}
+	if info.DNSSEC {
+		t.Errorf("DNSSEC = true, want false")
+	}
+	if info.Registrar != "Example Registrar, LLC" {
+		t.Errorf("Registrar = %q, want %q", info.Registrar, "Example Registrar, LLC")
+	}
+	if info.AbuseEmail != "abuse@example.com" {
+		t.Errorf("AbuseEmail = %q, want %q", info.AbuseEmail, "abuse@example.com")
+	}
+}
+
+func mustVCard(property string) *rdap.VCard {
+	blob := []byte(`["vcard", [["version", {}, "text", "4.0"], ` + property + `]]`)
+	vcard, err := rdap.NewVCard(blob)
+	if err != nil {
+		panic(err)
+	}
+	return vcard
+}
+
+func TestDomainInfoFromWhois(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     *WhoisResult
+		wantStatus string
+	}{
+		{
+			name:       "available",
+			result:     &WhoisResult{IsAvailable: boolPtr(true)},
+			wantStatus: StatusAvailable,
+		},
+		{
+			name:       "registered",
+			result:     &WhoisResult{IsAvailable: boolPtr(false)},
+			wantStatus: StatusRegistered,
+		},
+		{
+			name:       "not found with no IsAvailable flag is available, not registered",
+			result:     &WhoisResult{RawText: "No match for domain", RespType: whois.RespTypeNotFound},
+			wantStatus: StatusAvailable,
+		},
+		{
+			name:       "raw text with no IsAvailable flag and no not-found signal infers registered",
+			result:     &WhoisResult{RawText: "Domain Name: example.com"},
+			wantStatus: StatusRegistered,
+		},
+		{
+			name:       "nothing to go on stays unknown",
+			result:     &WhoisResult{},
+			wantStatus: StatusUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := domainInfoFromWhois(tc.result).Status; got != tc.wantStatus {
+				t.Errorf("domainInfoFromWhois().Status = %q, want %q", got, tc.wantStatus)
+			}
+		})
+	}
+
+	t.Run("populated fields are extracted", func(t *testing.T) {
+		result := &WhoisResult{
+			IsAvailable: boolPtr(false),
+			RespType:    whois.RespTypeFound,
+			Parsed: &whoisdomain.ParsedWhois{
+				NameServers: []string{"ns1.example.com", "ns2.example.com"},
+				Statuses:    []string{"clientTransferProhibited"},
+				CreatedDate: "2020-01-01T00:00:00+00:00",
+				UpdatedDate: "2024-01-01T00:00:00+00:00",
+				ExpiredDate: "2030-01-01T00:00:00+00:00",
+				Dnssec:      "signed",
+				Registrar: &whoisdomain.Registrar{
+					Name:              "Example Registrar, LLC",
+					AbuseContactEmail: "abuse@example.com",
+				},
+			},
+		}
+
+		info := domainInfoFromWhois(result)
+
+		if info.Source != "whois" {
+			t.Errorf("Source = %q, want %q", info.Source, "whois")
+		}
+		if info.WhoisResponseType != whois.RespTypeFound {
+			t.Errorf("WhoisResponseType = %q, want %q", info.WhoisResponseType, whois.RespTypeFound)
+		}
+		if want := []string{"ns1.example.com", "ns2.example.com"}; !reflect.DeepEqual(info.Nameservers, want) {
+			t.Errorf("Nameservers = %v, want %v", info.Nameservers, want)
+		}
+		if want := []string{"clientTransferProhibited"}; !reflect.DeepEqual(info.StatusCodes, want) {
+			t.Errorf("StatusCodes = %v, want %v", info.StatusCodes, want)
+		}
+		if info.CreatedDate != "2020-01-01T00:00:00+00:00" {
+			t.Errorf("CreatedDate = %q, want %q", info.CreatedDate, "2020-01-01T00:00:00+00:00")
+		}
+		if info.UpdatedDate != "2024-01-01T00:00:00+00:00" {
+			t.Errorf("UpdatedDate = %q, want %q", info.UpdatedDate, "2024-01-01T00:00:00+00:00")
+		}
+		if info.ExpirationDate != "2030-01-01T00:00:00+00:00" {
+			t.Errorf("ExpirationDate = %q, want %q", info.ExpirationDate, "2030-01-01T00:00:00+00:00")
+		}
+		if !info.DNSSEC {
+			t.Errorf("DNSSEC = false, want true")
+		}
+		if info.Registrar != "Example Registrar, LLC" {
+			t.Errorf("Registrar = %q, want %q", info.Registrar, "Example Registrar, LLC")
+		}
+		if info.AbuseEmail != "abuse@example.com" {
+			t.Errorf("AbuseEmail = %q, want %q", info.AbuseEmail, "abuse@example.com")
+		}
+	})
+}
+
+func TestFilterDomainInfoFields(t *testing.T) {
+	info := &DomainInfo{Status: StatusRegistered, Registrar: "Example Registrar, LLC", Source: "rdap"}
+
+	t.Run("no fields returns the whole object", func(t *testing.T) {
+		got, err := filterDomainInfoFields(info, nil)
+		if err != nil {
+			t.Fatalf("filterDomainInfoFields() error = %v", err)
+		}
+		if got != info {
+			t.Errorf("filterDomainInfoFields() = %v, want the original *DomainInfo", got)
+		}
+	})
+
+	t.Run("subset of fields", func(t *testing.T) {
+		got, err := filterDomainInfoFields(info, []string{"status", "registrar"})
+		if err != nil {
+			t.Fatalf("filterDomainInfoFields() error = %v", err)
+		}
+
+		raw, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("json.Marshal(got) error = %v", err)
+		}
+		var decoded map[string]string
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		want := map[string]string{"status": StatusRegistered, "registrar": "Example Registrar, LLC"}
+		if !reflect.DeepEqual(decoded, want) {
+			t.Errorf("filterDomainInfoFields() = %v, want %v", decoded, want)
+		}
+	})
+
+	t.Run("unknown field is silently dropped", func(t *testing.T) {
+		got, err := filterDomainInfoFields(info, []string{"not_a_real_field"})
+		if err != nil {
+			t.Fatalf("filterDomainInfoFields() error = %v", err)
+		}
+
+		raw, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("json.Marshal(got) error = %v", err)
+		}
+		if string(raw) != "{}" {
+			t.Errorf("filterDomainInfoFields() = %s, want {}", raw)
+		}
+	})
+}
+
 func TestLookupDomain(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -212,9 +493,11 @@ func TestLookupDomain(t *testing.T) {
 			mockWhoisProvider := NewMockWhoisProvider()
 			mockWhoisProvider.SetResponse(tc.domain, tc.mockWhoisResult, tc.mockWhoisError)
 
-			status := lookupDomain(mockRDAPClient, mockWhoisProvider, tc.domain)
-			if status != tc.expectedStatus {
-				t.Errorf("lookupDomain(%q) with RDAP error '%v' = %q, want %q", tc.domain, tc.mockRDAPError, status, tc.expectedStatus)
+			mockResolver := NewMockDNSResolver()
+
+			info := lookupDomain(mockRDAPClient, mockWhoisProvider, mockResolver, nil, tc.domain)
+			if info.Status != tc.expectedStatus {
+				t.Errorf("lookupDomain(%q) with RDAP error '%v' = %q, want %q", tc.domain, tc.mockRDAPError, info.Status, tc.expectedStatus)
 			}
 		})
 	}
@@ -224,11 +507,12 @@ func TestLookupDomainMCP(t *testing.T) {
 	tests := []struct {
 		name             string
 		domain           string
+		fields           []string
 		mockRDAPResponse *rdap.Response
 		mockRDAPError    error
 		mockWhoisResult  *WhoisResult
 		mockWhoisError   error
-		expectedJSON     string
+		expectedStatus   string
 	}{
 		{
 			name:             "Registered domain via MCP (RDAP success)",
@@ -237,7 +521,7 @@ func TestLookupDomainMCP(t *testing.T) {
 			mockRDAPError:    nil,
 			mockWhoisResult:  nil, // WHOIS not called when RDAP succeeds
 			mockWhoisError:   nil,
-			expectedJSON:     `{"example.com":"registered"}`,
+			expectedStatus:   StatusRegistered,
 		},
 		{
 			name:             "Domain via MCP (RDAP fails, WHOIS finds available)",
@@ -249,7 +533,7 @@ func TestLookupDomainMCP(t *testing.T) {
 				RawText:     "",
 			},
 			mockWhoisError: nil,
-			expectedJSON:   `{"nonexistent-domain-mcp.com":"available"}`,
+			expectedStatus: StatusAvailable,
 		},
 		{
 			name:             "Domain via MCP (RDAP fails, WHOIS finds registered)",
@@ -261,7 +545,15 @@ func TestLookupDomainMCP(t *testing.T) {
 				RawText:     "Domain Name: google.com",
 			},
 			mockWhoisError: nil,
-			expectedJSON:   `{"google.com":"registered"}`,
+			expectedStatus: StatusRegistered,
+		},
+		{
+			name:             "Fields filter returns only requested keys",
+			domain:           "example.com",
+			fields:           []string{"status"},
+			mockRDAPResponse: MockResponse("example.com"),
+			mockRDAPError:    nil,
+			expectedStatus:   StatusRegistered,
 		},
 	}
 
@@ -273,9 +565,11 @@ func TestLookupDomainMCP(t *testing.T) {
 			mockWhoisProvider := NewMockWhoisProvider()
 			mockWhoisProvider.SetResponse(tc.domain, tc.mockWhoisResult, tc.mockWhoisError)
 
-			args := SingleDomainLookup{Domain: tc.domain}
+			mockResolver := NewMockDNSResolver()
+
+			args := SingleDomainLookup{Domain: tc.domain, Fields: tc.fields}
 
-			resp, err := lookupDomainMCP(mockRDAPClient, mockWhoisProvider, args)
+			resp, err := lookupDomainMCP(mockRDAPClient, mockWhoisProvider, mockResolver, nil, args)
 
 			if err != nil {
 				t.Errorf("lookupDomainMCP() error = %v, want nil", err)
@@ -287,10 +581,20 @@ func TestLookupDomainMCP(t *testing.T) {
 				t.Fatalf("lookupDomainMCP() response has no content")
 			}
 
-			textContent := resp.Content[0].TextContent.Text
+			var result map[string]map[string]interface{}
+			if err := json.Unmarshal([]byte(resp.Content[0].TextContent.Text), &result); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v. Response was: %s", err, resp.Content[0].TextContent.Text)
+			}
 
-			if textContent != tc.expectedJSON {
-				t.Errorf("lookupDomainMCP() result JSON = %q, want %q", textContent, tc.expectedJSON)
+			domainResult, ok := result[tc.domain]
+			if !ok {
+				t.Fatalf("lookupDomainMCP() result missing key %q: %v", tc.domain, result)
+			}
+			if domainResult["status"] != tc.expectedStatus {
+				t.Errorf("lookupDomainMCP() status = %v, want %q", domainResult["status"], tc.expectedStatus)
+			}
+			if len(tc.fields) > 0 && len(domainResult) != len(tc.fields) {
+				t.Errorf("lookupDomainMCP() with fields filter returned %d keys, want %d: %v", len(domainResult), len(tc.fields), domainResult)
 			}
 		})
 	}
@@ -318,9 +622,11 @@ func TestLookupDomainsMCP(t *testing.T) {
 	}, nil)
 	// No need to set up example.com because RDAP will succeed
 
+	mockResolver := NewMockDNSResolver()
+
 	args := MultipleDomainsLookup{Domains: domains}
 	// Call MCP handler with both mock clients
-	resp, err := lookupDomainsMCP(mockRDAPClient, mockWhoisProvider, args)
+	resp, err := lookupDomainsMCP(mockRDAPClient, mockWhoisProvider, mockResolver, nil, args)
 
 	if err != nil {
 		t.Errorf("lookupDomainsMCP() error = %v, want nil", err)
@@ -333,31 +639,37 @@ func TestLookupDomainsMCP(t *testing.T) {
 	}
 
 	textContent := resp.Content[0].TextContent.Text
-	var result map[string]string
+	var result map[string]DomainInfo
 	err = json.Unmarshal([]byte(textContent), &result)
 	if err != nil {
 		t.Fatalf("Failed to unmarshal response: %v. Response was: %s", err, textContent)
 	}
 
-	// Define expected results based on RDAP mock and WHOIS mock behavior
+	// Define expected statuses based on RDAP mock and WHOIS mock behavior
 	expected := map[string]string{
 		"example.com":                      StatusRegistered, // Found by RDAP mock
 		"nonexistent-domain-mcp-multi.com": StatusAvailable,  // RDAP fails, mock WHOIS finds available
 		"google.com":                       StatusRegistered, // RDAP fails, mock WHOIS finds registered
 	}
 
-	if !reflect.DeepEqual(result, expected) {
-		t.Errorf("lookupDomainsMCP() result = %v, want %v", result, expected)
+	got := make(map[string]string, len(result))
+	for domain, info := range result {
+		got[domain] = info.Status
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("lookupDomainsMCP() result = %v, want %v", got, expected)
 	}
 }
 
 func TestEmptyDomainsLookup(t *testing.T) {
 	mockRDAPClient := NewMockRDAPClient()
 	mockWhoisProvider := NewMockWhoisProvider()
+	mockResolver := NewMockDNSResolver()
 	args := MultipleDomainsLookup{Domains: []string{}}
 
 	// Pass both mock clients
-	resp, err := lookupDomainsMCP(mockRDAPClient, mockWhoisProvider, args)
+	resp, err := lookupDomainsMCP(mockRDAPClient, mockWhoisProvider, mockResolver, nil, args)
 
 	if err != nil {
 		t.Errorf("lookupDomainsMCP() with empty domains error = %v, want nil", err)
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openrdap/rdap"
+	"github.com/openrdap/rdap/bootstrap"
+	"github.com/shlin168/go-whois/whois"
+	"github.com/shlin168/go-whois/whois/utils"
+)
+
+// ErrRateLimited marks a lookup that our own outbound rate limiter blocked
+// until its context ran out, or that an upstream server itself rejected
+// with a 429, rather than a genuine lookup failure.
+var ErrRateLimited = errors.New("rate limited")
+
+const (
+	defaultRateLimitQPS   = 2.0
+	defaultRateLimitBurst = 4
+)
+
+// RateLimiter is a token-bucket limiter: tokens refill continuously at qps
+// per second up to burst capacity, and Wait blocks until one is available.
+type RateLimiter struct {
+	mu         sync.Mutex
+	qps        float64
+	burst      float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func NewRateLimiter(qps float64, burst int) *RateLimiter {
+	if qps <= 0 {
+		qps = defaultRateLimitQPS
+	}
+	if burst <= 0 {
+		burst = defaultRateLimitBurst
+	}
+	return &RateLimiter{
+		qps:        qps,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		lastRefill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available, or ctx is done.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	wait := r.reserve()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve refills the bucket for elapsed time, consumes a token if one is
+// available, and otherwise returns how long the caller must wait for one.
+func (r *RateLimiter) reserve() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.tokens = min(r.burst, r.tokens+now.Sub(r.lastRefill).Seconds()*r.qps)
+	r.lastRefill = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - r.tokens) / r.qps * float64(time.Second))
+}
+
+// HostRateLimit is a per-host QPS/burst override.
+type HostRateLimit struct {
+	QPS   float64 `json:"qps"`
+	Burst int     `json:"burst"`
+}
+
+// RateLimitConfig is the shape of the optional JSON file passed via
+// -rate-limit-config, letting operators throttle specific registries (e.g. a
+// ccTLD WHOIS server known to ban aggressively) without recompiling.
+type RateLimitConfig struct {
+	DefaultQPS   float64                  `json:"default_qps,omitempty"`
+	DefaultBurst int                      `json:"default_burst,omitempty"`
+	Hosts        map[string]HostRateLimit `json:"hosts,omitempty"`
+}
+
+func loadRateLimitConfig(path string) (*RateLimitConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit config %s: %w", path, err)
+	}
+
+	var cfg RateLimitConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// HostRateLimiter hands out a RateLimiter per host (a WHOIS server, or an
+// RDAP base URL's host), using a configurable default QPS/burst unless a
+// host-specific override applies.
+type HostRateLimiter struct {
+	mu           sync.Mutex
+	limiters     map[string]*RateLimiter
+	defaultQPS   float64
+	defaultBurst int
+	overrides    map[string]HostRateLimit
+}
+
+func NewHostRateLimiter(defaultQPS float64, defaultBurst int, cfg *RateLimitConfig) *HostRateLimiter {
+	h := &HostRateLimiter{
+		limiters:     make(map[string]*RateLimiter),
+		defaultQPS:   defaultQPS,
+		defaultBurst: defaultBurst,
+	}
+
+	if cfg != nil {
+		if cfg.DefaultQPS > 0 {
+			h.defaultQPS = cfg.DefaultQPS
+		}
+		if cfg.DefaultBurst > 0 {
+			h.defaultBurst = cfg.DefaultBurst
+		}
+		h.overrides = cfg.Hosts
+	}
+	return h
+}
+
+// Wait blocks until a request to host is permitted under its rate limit.
+func (h *HostRateLimiter) Wait(ctx context.Context, host string) error {
+	if err := h.limiterFor(host).Wait(ctx); err != nil {
+		return fmt.Errorf("%w: waiting for %s: %v", ErrRateLimited, host, err)
+	}
+	return nil
+}
+
+func (h *HostRateLimiter) limiterFor(host string) *RateLimiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if l, ok := h.limiters[host]; ok {
+		return l
+	}
+
+	qps, burst := h.defaultQPS, h.defaultBurst
+	if override, ok := h.overrides[host]; ok {
+		if override.QPS > 0 {
+			qps = override.QPS
+		}
+		if override.Burst > 0 {
+			burst = override.Burst
+		}
+	}
+
+	l := NewRateLimiter(qps, burst)
+	h.limiters[host] = l
+	return l
+}
+
+// rateLimitedRDAPRequestError carries the Retry-After hint from an RDAP
+// server's 429 response, so lookupWithRDAP can surface StatusRateLimited
+// instead of treating the tier as a plain failure.
+type rateLimitedRDAPRequestError struct {
+	retryAfter string
+}
+
+func (e *rateLimitedRDAPRequestError) Error() string {
+	return "RDAP server responded 429 Too Many Requests"
+}
+
+func (e *rateLimitedRDAPRequestError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RDAPBootstrapper resolves RDAP base URLs for a bootstrap query; satisfied
+// by *bootstrap.Client, and mockable in tests to avoid a real IANA lookup.
+type RDAPBootstrapper interface {
+	Lookup(question *bootstrap.Question) (*bootstrap.Answer, error)
+}
+
+// RateLimitedRDAPClient wraps an RDAPClient, resolving the RDAP base URL's
+// host via the IANA bootstrap registry before the real request so a
+// per-host rate limit can be applied even on the very first query to a
+// given registry.
+type RateLimitedRDAPClient struct {
+	client    RDAPClient
+	bootstrap RDAPBootstrapper
+	limiter   *HostRateLimiter
+}
+
+func NewRateLimitedRDAPClient(client RDAPClient, limiter *HostRateLimiter) *RateLimitedRDAPClient {
+	return &RateLimitedRDAPClient{client: client, bootstrap: &bootstrap.Client{}, limiter: limiter}
+}
+
+func (c *RateLimitedRDAPClient) Do(req *rdap.Request) (*rdap.Response, error) {
+	host, err := c.rdapHost(req)
+	if err != nil {
+		log.Printf("RDAP bootstrap lookup failed for %q, skipping rate limiting: %v", req.Query, err)
+		return c.client.Do(req)
+	}
+
+	if err := c.limiter.Wait(req.Context(), host); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if retryAfter, limited := rdapRetryAfter(resp); limited {
+		return resp, &rateLimitedRDAPRequestError{retryAfter: retryAfterSeconds(retryAfter)}
+	}
+	return resp, err
+}
+
+// rdapHost resolves the host a request will be sent to, via the IANA
+// bootstrap registry when the request doesn't already pin a server.
+func (c *RateLimitedRDAPClient) rdapHost(req *rdap.Request) (string, error) {
+	if req.Server != nil {
+		return req.Server.Host, nil
+	}
+
+	registryType, ok := bootstrapRegistryFor(req)
+	if !ok {
+		return "", fmt.Errorf("no bootstrap registry for request type %s", req.Type)
+	}
+
+	answer, err := c.bootstrap.Lookup(&bootstrap.Question{RegistryType: registryType, Query: req.Query})
+	if err != nil {
+		return "", err
+	}
+	if len(answer.URLs) == 0 {
+		return "", fmt.Errorf("no RDAP servers found for %q", req.Query)
+	}
+	return answer.URLs[0].Host, nil
+}
+
+// bootstrapRegistryFor mirrors the request-type-to-registry mapping rdap.Client
+// uses internally, so we can bootstrap the same host it will.
+func bootstrapRegistryFor(req *rdap.Request) (bootstrap.RegistryType, bool) {
+	switch req.Type {
+	case rdap.DomainRequest:
+		return bootstrap.DNS, true
+	case rdap.AutnumRequest:
+		return bootstrap.ASN, true
+	case rdap.IPRequest:
+		if strings.Contains(req.Query, ":") {
+			return bootstrap.IPv6, true
+		}
+		return bootstrap.IPv4, true
+	default:
+		return 0, false
+	}
+}
+
+// rdapRetryAfter scans an RDAP response's HTTP attempts for a 429, returning
+// its Retry-After header (if any) and whether one was found.
+func rdapRetryAfter(resp *rdap.Response) (string, bool) {
+	if resp == nil {
+		return "", false
+	}
+
+	for _, httpResp := range resp.HTTP {
+		if httpResp == nil || httpResp.Response == nil {
+			continue
+		}
+		if httpResp.Response.StatusCode == http.StatusTooManyRequests {
+			return httpResp.Response.Header.Get("Retry-After"), true
+		}
+	}
+	return "", false
+}
+
+// RateLimitedWhoisProvider wraps a WhoisProvider, resolving the WHOIS server
+// host that go-whois would query for a domain via its public server-list
+// lookup, so a per-host rate limit can be applied before issuing the query.
+type RateLimitedWhoisProvider struct {
+	provider  WhoisProvider
+	serverMap whois.DomainWhoisServerMap
+	limiter   *HostRateLimiter
+}
+
+func NewRateLimitedWhoisProvider(provider WhoisProvider, serverMap whois.DomainWhoisServerMap, limiter *HostRateLimiter) *RateLimitedWhoisProvider {
+	return &RateLimitedWhoisProvider{provider: provider, serverMap: serverMap, limiter: limiter}
+}
+
+func (p *RateLimitedWhoisProvider) Query(ctx context.Context, domain string) (*WhoisResult, error) {
+	if host, ok := p.whoisHost(domain); ok {
+		if err := p.limiter.Wait(ctx, host); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Printf("No known WHOIS server for %q, skipping rate limiting", domain)
+	}
+
+	return p.provider.Query(ctx, domain)
+}
+
+// whoisHost resolves the WHOIS server host go-whois's own Query would use,
+// via the same public suffix lookup it performs internally.
+func (p *RateLimitedWhoisProvider) whoisHost(domain string) (string, bool) {
+	host, err := utils.GetHost(domain)
+	if err != nil {
+		return "", false
+	}
+
+	pslist, err := utils.GetPublicSuffixs(host)
+	if err != nil || len(pslist) == 0 {
+		return "", false
+	}
+
+	servers := p.serverMap.GetWhoisServer(pslist[0])
+	if len(servers) == 0 {
+		return "", false
+	}
+	return servers[0].Host, true
+}
+
+// retryAfterSeconds parses a Retry-After header value, which RFC 7231 allows
+// to be either a number of seconds or an HTTP date; only the common
+// seconds form is surfaced, since that's what RDAP servers send in practice.
+func retryAfterSeconds(value string) string {
+	if value == "" {
+		return ""
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openrdap/rdap"
+	"github.com/openrdap/rdap/bootstrap"
+	"github.com/shlin168/go-whois/whois"
+)
+
+var _ RDAPBootstrapper = (*MockRDAPBootstrapper)(nil)
+
+type MockRDAPBootstrapper struct {
+	answers map[string]*bootstrap.Answer
+	errs    map[string]error
+}
+
+func NewMockRDAPBootstrapper() *MockRDAPBootstrapper {
+	return &MockRDAPBootstrapper{
+		answers: make(map[string]*bootstrap.Answer),
+		errs:    make(map[string]error),
+	}
+}
+
+func (m *MockRDAPBootstrapper) SetAnswer(query, host string) {
+	m.answers[query] = &bootstrap.Answer{Query: query, URLs: []*url.URL{{Scheme: "https", Host: host}}}
+}
+
+func (m *MockRDAPBootstrapper) SetError(query string, err error) {
+	m.errs[query] = err
+}
+
+func (m *MockRDAPBootstrapper) Lookup(question *bootstrap.Question) (*bootstrap.Answer, error) {
+	if err, ok := m.errs[question.Query]; ok {
+		return nil, err
+	}
+	if answer, ok := m.answers[question.Query]; ok {
+		return answer, nil
+	}
+	return nil, errors.New("unexpected bootstrap lookup")
+}
+
+func TestRateLimiterAllowsBurstThenThrottles(t *testing.T) {
+	limiter := NewRateLimiter(1000, 2)
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() burst call %d error = %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait() for burst tokens took %v, want near-instant", elapsed)
+	}
+
+	slow := NewRateLimiter(20, 1)
+	if err := slow.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() first call error = %v", err)
+	}
+	start = time.Now()
+	if err := slow.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() second call error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Errorf("Wait() after exhausting burst returned after %v, want a delay close to 1/qps", elapsed)
+	}
+}
+
+func TestRateLimiterWaitRespectsContextCancellation(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+	limiter.Wait(context.Background()) // consume the only token
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestHostRateLimiterIsolatesHosts(t *testing.T) {
+	h := NewHostRateLimiter(1, 1, nil)
+
+	if err := h.Wait(context.Background(), "a.example"); err != nil {
+		t.Fatalf("Wait(a.example) error = %v", err)
+	}
+
+	// "b.example" has its own bucket, so it shouldn't be throttled by
+	// "a.example" having just consumed its only token.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.Wait(ctx, "b.example"); err != nil {
+		t.Errorf("Wait(b.example) = %v, want nil (independent bucket from a.example)", err)
+	}
+}
+
+func TestHostRateLimiterAppliesConfigOverride(t *testing.T) {
+	cfg := &RateLimitConfig{
+		DefaultQPS:   1,
+		DefaultBurst: 1,
+		Hosts: map[string]HostRateLimit{
+			"generous.example": {QPS: 1000, Burst: 5},
+		},
+	}
+	h := NewHostRateLimiter(1, 1, cfg)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := h.Wait(context.Background(), "generous.example"); err != nil {
+			t.Fatalf("Wait(generous.example) call %d error = %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait() for overridden host took %v, want near-instant given its burst of 5", elapsed)
+	}
+}
+
+func TestLoadRateLimitConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rate-limits.json")
+	contents := `{"default_qps": 5, "default_burst": 10, "hosts": {"whois.verisign-grs.com": {"qps": 1, "burst": 1}}}`
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write test fixture %s: %v", path, err)
+	}
+
+	cfg, err := loadRateLimitConfig(path)
+	if err != nil {
+		t.Fatalf("loadRateLimitConfig() error = %v", err)
+	}
+	if cfg.DefaultQPS != 5 || cfg.DefaultBurst != 10 {
+		t.Errorf("loadRateLimitConfig() defaults = %v/%v, want 5/10", cfg.DefaultQPS, cfg.DefaultBurst)
+	}
+	if got := cfg.Hosts["whois.verisign-grs.com"]; got.QPS != 1 || got.Burst != 1 {
+		t.Errorf("loadRateLimitConfig() host override = %+v, want {QPS:1 Burst:1}", got)
+	}
+}
+
+func TestLoadRateLimitConfigMissingFile(t *testing.T) {
+	if _, err := loadRateLimitConfig(filepath.Join(t.TempDir(), "does-not-exist.json")); err == nil {
+		t.Error("loadRateLimitConfig() error = nil, want an error for a missing file")
+	}
+}
+
+func TestRateLimitedRDAPClientAppliesPerHostLimit(t *testing.T) {
+	bootstrapper := NewMockRDAPBootstrapper()
+	bootstrapper.SetAnswer("example.com", "rdap.verisign.com")
+
+	inner := NewMockRDAPClient()
+	inner.SetResponse("example.com", MockResponse("example.com"), nil)
+
+	client := &RateLimitedRDAPClient{
+		client:    inner,
+		bootstrap: bootstrapper,
+		limiter:   NewHostRateLimiter(1000, 1, nil),
+	}
+
+	req := rdap.NewRequest(rdap.DomainRequest, "example.com")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp == nil {
+		t.Fatal("Do() returned nil response")
+	}
+}
+
+func TestRateLimitedRDAPClientSurfacesRetryAfter(t *testing.T) {
+	bootstrapper := NewMockRDAPBootstrapper()
+	bootstrapper.SetAnswer("example.com", "rdap.verisign.com")
+
+	inner := &fixedStatusRDAPClient{statusCode: http.StatusTooManyRequests, retryAfter: "30"}
+
+	client := &RateLimitedRDAPClient{
+		client:    inner,
+		bootstrap: bootstrapper,
+		limiter:   NewHostRateLimiter(1000, 1, nil),
+	}
+
+	_, err := client.Do(rdap.NewRequest(rdap.DomainRequest, "example.com"))
+
+	var rlErr *rateLimitedRDAPRequestError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("Do() error = %v, want a *rateLimitedRDAPRequestError", err)
+	}
+	if rlErr.retryAfter != "30" {
+		t.Errorf("rateLimitedRDAPRequestError.retryAfter = %q, want %q", rlErr.retryAfter, "30")
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Do() error does not wrap ErrRateLimited")
+	}
+}
+
+func TestRateLimitedRDAPClientFallsBackWithoutBootstrapMatch(t *testing.T) {
+	bootstrapper := NewMockRDAPBootstrapper()
+	bootstrapper.SetError("example.com", errors.New("no bootstrap entry"))
+
+	inner := NewMockRDAPClient()
+	inner.SetResponse("example.com", MockResponse("example.com"), nil)
+
+	client := &RateLimitedRDAPClient{
+		client:    inner,
+		bootstrap: bootstrapper,
+		limiter:   NewHostRateLimiter(1000, 1, nil),
+	}
+
+	resp, err := client.Do(rdap.NewRequest(rdap.DomainRequest, "example.com"))
+	if err != nil {
+		t.Fatalf("Do() error = %v, want the underlying client's response when bootstrap fails", err)
+	}
+	if resp == nil {
+		t.Error("Do() returned nil response")
+	}
+}
+
+// fixedStatusRDAPClient simulates an RDAP server that always responds with a
+// fixed HTTP status code and Retry-After header.
+type fixedStatusRDAPClient struct {
+	statusCode int
+	retryAfter string
+}
+
+func (f *fixedStatusRDAPClient) Do(req *rdap.Request) (*rdap.Response, error) {
+	header := http.Header{}
+	if f.retryAfter != "" {
+		header.Set("Retry-After", f.retryAfter)
+	}
+	return &rdap.Response{
+		HTTP: []*rdap.HTTPResponse{
+			{Response: &http.Response{StatusCode: f.statusCode, Header: header}},
+		},
+	}, nil
+}
+
+func TestRateLimitedWhoisProviderAppliesPerHostLimit(t *testing.T) {
+	serverMap := whois.DomainWhoisServerMap{
+		"com": []whois.WhoisServer{{Host: "whois.verisign-grs.com"}},
+	}
+
+	inner := NewMockWhoisProvider()
+	inner.SetResponse("example.com", &WhoisResult{IsAvailable: boolPtr(false)}, nil)
+
+	provider := NewRateLimitedWhoisProvider(inner, serverMap, NewHostRateLimiter(1000, 1, nil))
+
+	result, err := provider.Query(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if result == nil || result.IsAvailable == nil || *result.IsAvailable {
+		t.Errorf("Query() = %+v, want the underlying provider's result", result)
+	}
+}
+
+func TestRateLimitedWhoisProviderSurfacesRateLimit(t *testing.T) {
+	serverMap := whois.DomainWhoisServerMap{
+		"com": []whois.WhoisServer{{Host: "whois.verisign-grs.com"}},
+	}
+
+	inner := NewMockWhoisProvider()
+	inner.SetResponse("example.com", &WhoisResult{IsAvailable: boolPtr(false)}, nil)
+
+	limiter := NewHostRateLimiter(1, 1, nil)
+	provider := NewRateLimitedWhoisProvider(inner, serverMap, limiter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Exhaust the token the 10ms-bounded call below needs to wait for.
+	limiter.Wait(context.Background(), "whois.verisign-grs.com")
+
+	_, err := provider.Query(ctx, "example.com")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Query() error = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"seconds", "120", "120"},
+		{"http date is unsupported", "Wed, 21 Oct 2026 07:28:00 GMT", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := retryAfterSeconds(tc.value); got != tc.want {
+				t.Errorf("retryAfterSeconds(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
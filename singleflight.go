@@ -0,0 +1,43 @@
+package main
+
+import "sync"
+
+// singleflightGroup coalesces concurrent lookupDomain calls for the same
+// domain so a batch of duplicates in lookupDomainsMCP only issues one
+// upstream request; the other callers just wait for and share that result.
+type singleflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*singleflightCall
+}
+
+type singleflightCall struct {
+	wg    sync.WaitGroup
+	value *DomainInfo
+}
+
+func newSingleflightGroup() *singleflightGroup {
+	return &singleflightGroup{calls: make(map[string]*singleflightCall)}
+}
+
+func (g *singleflightGroup) Do(key string, fn func() *DomainInfo) *DomainInfo {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.value
+	}
+
+	call := &singleflightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.value = fn()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.value
+}